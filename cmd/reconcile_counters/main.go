@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"restapp/config"
+	"restapp/internal/database"
+	"restapp/internal/repositories"
+	"restapp/internal/services"
+)
+
+func main() {
+	cfg := config.MustLoad("../../config/config.yaml")
+	err := database.InitDB(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	db := database.GetDB()
+	articleRepo := repositories.NewArticleRepository(db)
+	mentionService := services.NewMentionService(repositories.NewMentionRepository(db))
+	userRepo := repositories.NewUserRepository(db)
+	articleService := services.NewArticleService(articleRepo, userRepo, mentionService, cfg)
+
+	fixed, err := articleService.ReconcileCounters(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	log.Printf("Counters reconciled, %d articles fixed", fixed)
+}
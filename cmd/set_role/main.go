@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"restapp/config"
+	"restapp/internal/database"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+)
+
+func main() {
+	email := flag.String("email", "", "user email")
+	role := flag.String("role", models.RoleAdmin, "role to assign")
+	flag.Parse()
+
+	if *email == "" || !models.IsValidRole(*role) {
+		flag.Usage()
+		return
+	}
+
+	cfg := config.MustLoad("../../config/config.yaml")
+	err := database.InitDB(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	db := database.GetDB()
+	authRepo := repositories.NewAuthRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+
+	ctx := context.Background()
+	user, err := authRepo.GetUserByEmail(ctx, *email)
+	if err != nil {
+		panic(err)
+	}
+
+	if models.RequiresMfa(*role) && user.MfaEnabledAt == nil {
+		log.Fatalf("User %s must enable two-factor authentication before becoming %s", user.Email, *role)
+	}
+
+	err = userRepo.UpdateRole(ctx, user.Id, *role)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = tokenRepo.BumpTokenVersion(ctx, user.Id)
+	if err != nil {
+		panic(err)
+	}
+
+	log.Printf("User %s is now %s", user.Email, *role)
+}
@@ -7,6 +7,7 @@ import (
 type Config struct {
 	App struct {
 		Name string `yaml:"name"`
+		URL  string `yaml:"url"`
 	}
 
 	Server struct {
@@ -24,9 +25,88 @@ type Config struct {
 	}
 
 	JWT struct {
-		Secret     string `yaml:"secret"`
-		Expiration string `yaml:"expiration"`
+		Secret            string   `yaml:"secret"`
+		Expiration        string   `yaml:"expiration"`
+		RefreshExpiration string   `yaml:"refresh_expiration"`
+		RevocationSync    int      `yaml:"revocation_sync"`
+		KeyOverlap        int      `yaml:"key_overlap"`
+		Keys              []JWTKey `yaml:"keys"`
 	}
+
+	Auth struct {
+		ResetExpiration        int    `yaml:"reset_expiration"`
+		VerificationExpiration int    `yaml:"verification_expiration"`
+		VerificationResend     int    `yaml:"verification_resend"`
+		LoginMaxAttempts       int    `yaml:"login_max_attempts"`
+		LoginIPMaxAttempts     int    `yaml:"login_ip_max_attempts"`
+		LoginWindow            int    `yaml:"login_window"`
+		LoginLockout           int    `yaml:"login_lockout"`
+		LoginBackoffBase       int    `yaml:"login_backoff_base"`
+		LoginBackoffMax        int    `yaml:"login_backoff_max"`
+		MfaIssuer              string `yaml:"mfa_issuer"`
+		MfaChallengeExpiration int    `yaml:"mfa_challenge_expiration"`
+		SessionTouchInterval   int    `yaml:"session_touch_interval"`
+	}
+
+	Mail struct {
+		Driver   string `yaml:"driver"`
+		From     string `yaml:"from"`
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		Dir      string `yaml:"dir"`
+	}
+
+	Account struct {
+		ExportDir        string `yaml:"export_dir"`
+		ExportExpiration int    `yaml:"export_expiration"`
+		DeletionGrace    int    `yaml:"deletion_grace"`
+		ArticlePolicy    string `yaml:"article_policy"`
+		ReassignTo       string `yaml:"reassign_to"`
+		JobInterval      int    `yaml:"job_interval"`
+	}
+
+	OIDC struct {
+		StateExpiration int                     `yaml:"state_expiration"`
+		Providers       map[string]OIDCProvider `yaml:"providers"`
+	}
+
+	Reactions struct {
+		Types map[string]string `yaml:"types"`
+	}
+
+	Feed struct {
+		PageSize    int `yaml:"page_size"`
+		MaxPageSize int `yaml:"max_page_size"`
+	}
+
+	Comments struct {
+		MaxDepth    int `yaml:"max_depth" env-default:"8"`
+		LoadDepth   int `yaml:"load_depth" env-default:"3"`
+		PageSize    int `yaml:"page_size" env-default:"20"`
+		MaxPageSize int `yaml:"max_page_size" env-default:"100"`
+		InlineLimit int `yaml:"inline_limit" env-default:"10"`
+		MaxReplies  int `yaml:"max_replies" env-default:"200"`
+	}
+}
+
+type JWTKey struct {
+	Kid        string `yaml:"kid"`
+	File       string `yaml:"file"`
+	ActiveFrom string `yaml:"active_from"`
+}
+
+type OIDCProvider struct {
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+	AuthURL      string   `yaml:"auth_url"`
+	TokenURL     string   `yaml:"token_url"`
+	UserInfoURL  string   `yaml:"userinfo_url"`
+	TrustEmail   bool     `yaml:"trust_email"`
 }
 
 func MustLoad(cfgPath string) *Config {
@@ -1,13 +1,19 @@
 package app
 
 import (
+	"context"
 	"log"
 	"restapp/config"
 	"restapp/internal/database"
 	"restapp/internal/delivery/rest"
+	"restapp/internal/keyring"
+	"restapp/internal/mailer"
 	"restapp/internal/middlewares"
+	"restapp/internal/models"
+	"restapp/internal/oidc"
 	"restapp/internal/repositories"
 	"restapp/internal/services"
+	"time"
 
 	"github.com/labstack/echo-contrib/echoprometheus"
 	"github.com/labstack/echo/v4"
@@ -45,35 +51,138 @@ func (a *App) Run() {
 
 	articleRepo := repositories.NewArticleRepository(db)
 	authRepo := repositories.NewAuthRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+	passwordRepo := repositories.NewPasswordRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	mfaRepo := repositories.NewMfaRepository(db)
+	oidcRepo := repositories.NewOIDCRepository(db)
+	personalTokenRepo := repositories.NewPersonalTokenRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
 	commentRepo := repositories.NewCommentRepository(db)
+	followRepo := repositories.NewFollowRepository(db)
+	mentionRepo := repositories.NewMentionRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
 
-	articleService := services.NewArticleService(articleRepo)
-	authService := services.NewAuthService(authRepo, cfg)
-	commentService := services.NewCommentService(commentRepo)
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	keys, err := keyring.New(cfg)
+	if err != nil {
+		panic(err)
+	}
 
-	articleHandler := rest.NewArticleHandler(articleService, authService, commentService)
+	mentionService := services.NewMentionService(mentionRepo)
+	articleService := services.NewArticleService(articleRepo, userRepo, mentionService, cfg)
+	authService := services.NewAuthService(authRepo, userRepo, tokenRepo, passwordRepo, loginAttemptRepo, mfaRepo, personalTokenRepo, mail, keys, cfg)
+	accountService := services.NewAccountService(accountRepo, userRepo, cfg)
+	followService := services.NewFollowService(followRepo, userRepo, cfg)
+	feedService := services.NewFeedService(services.NewFanOutOnReadFeed(articleRepo), mentionService, cfg)
+	commentService := services.NewCommentService(commentRepo, userRepo, followRepo, mentionService, cfg)
+	notificationService := services.NewNotificationService(notificationRepo)
+	userService := services.NewUserService(userRepo)
+	oidcService := services.NewOIDCService(oidc.NewProviders(cfg), oidcRepo, authRepo, userRepo, authService, cfg)
+
+	articleHandler := rest.NewArticleHandler(articleService, commentService, cfg.Comments.InlineLimit)
 	authHandler := rest.NewAuthHandler(authService)
-	commentHandler := rest.NewCommentHandler(commentService, authService)
+	commentHandler := rest.NewCommentHandler(commentService)
+	notificationHandler := rest.NewNotificationHandler(notificationService)
+	adminHandler := rest.NewAdminHandler(authService)
+	userHandler := rest.NewUserHandler(userService, authService, accountService)
+	oidcHandler := rest.NewOIDCHandler(oidcService)
+	followHandler := rest.NewFollowHandler(followService, feedService)
 
 	authMiddleware := middlewares.NewAuthMiddleware(authService)
 
+	err = authService.SyncRevocations(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	go a.syncRevocations(authService, time.Duration(cfg.JWT.RevocationSync)*time.Second)
+	go a.runAccountJobs(accountService, time.Duration(cfg.Account.JobInterval)*time.Second)
+
 	e.GET("/metrics", echoprometheus.NewHandler())
+	e.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	auth := e.Group("/auth")
 	auth.POST("/register", authHandler.Register)
 	auth.POST("/login", authHandler.Login)
+	auth.POST("/refresh", authHandler.Refresh)
+	auth.POST("/logout", authHandler.Logout, authMiddleware.AuthMiddleware, authMiddleware.RequireSession)
+	auth.POST("/password/forgot", authHandler.ForgotPassword)
+	auth.POST("/password/reset", authHandler.ResetPassword)
+	auth.POST("/mfa/verify", authHandler.VerifyMfa)
+	auth.GET("/oidc/:provider/login", oidcHandler.Login)
+	auth.GET("/oidc/:provider/callback", oidcHandler.Callback)
+	auth.GET("/verify", authHandler.VerifyEmail)
+	auth.POST("/verify/resend", authHandler.ResendVerification, authMiddleware.AuthMiddleware, authMiddleware.RequireSession)
 
 	articles := e.Group("/articles")
 	articles.Use(authMiddleware.AuthMiddleware)
-	articles.GET("", articleHandler.GetAllArticles)
-	articles.GET("/:id", articleHandler.GetById)
-	articles.POST("", articleHandler.StoreArticle)
-	articles.PUT("/:id", articleHandler.UpdateArticle)
-	articles.DELETE("/:id", articleHandler.DeleteArticle)
-	articles.GET("/:id/like", articleHandler.LikeArticle)
-	articles.GET("/:id/unlike", articleHandler.UnlikeArticle)
-
-	articles.POST("/:id/comments", commentHandler.CreateComment)
+	articles.GET("", articleHandler.GetAllArticles, authMiddleware.RequireScope(models.ScopeArticlesRead), authMiddleware.RequirePermission(models.PermArticleRead))
+	articles.GET("/:id", articleHandler.GetById, authMiddleware.RequireScope(models.ScopeArticlesRead), authMiddleware.RequirePermission(models.PermArticleRead))
+	articles.POST("", articleHandler.StoreArticle, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleCreate))
+	articles.PUT("/:id", articleHandler.UpdateArticle, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleUpdateOwn))
+	articles.DELETE("/:id", articleHandler.DeleteArticle, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleDeleteOwn))
+	articles.PUT("/:id/like", articleHandler.LikeArticle, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleReact))
+	articles.DELETE("/:id/like", articleHandler.UnlikeArticle, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleReact))
+	articles.PUT("/:id/reactions/:type", articleHandler.React, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleReact))
+	articles.DELETE("/:id/reactions/:type", articleHandler.Unreact, authMiddleware.RequireScope(models.ScopeArticlesWrite), authMiddleware.RequirePermission(models.PermArticleReact))
+
+	articles.GET("/:id/comments", commentHandler.GetAllComments, authMiddleware.RequireScope(models.ScopeArticlesRead))
+	articles.POST("/:id/comments", commentHandler.CreateComment, authMiddleware.RequireScope(models.ScopeCommentsWrite), authMiddleware.RequirePermission(models.PermCommentCreate))
+	articles.GET("/:id/comments/settings", commentHandler.GetSettings, authMiddleware.RequireScope(models.ScopeArticlesRead))
+	articles.PUT("/:id/comments/settings", commentHandler.UpdateSettings, authMiddleware.RequireScope(models.ScopeCommentsWrite))
+	articles.GET("/:id/comments/pending", commentHandler.GetPendingComments, authMiddleware.RequireScope(models.ScopeArticlesRead))
+	articles.POST("/:id/comments/:commentId/approve", commentHandler.ApproveComment, authMiddleware.RequireScope(models.ScopeCommentsWrite))
+	articles.POST("/:id/comments/:commentId/reject", commentHandler.RejectComment, authMiddleware.RequireScope(models.ScopeCommentsWrite))
+	articles.GET("/:id/comments/:commentId", commentHandler.GetComment, authMiddleware.RequireScope(models.ScopeArticlesRead))
+	articles.PUT("/:id/comments/:commentId", commentHandler.UpdateComment, authMiddleware.RequireScope(models.ScopeCommentsWrite))
+	articles.DELETE("/:id/comments/:commentId", commentHandler.DeleteComment, authMiddleware.RequireScope(models.ScopeCommentsWrite))
+	articles.GET("/:id/comments/:commentId/replies", commentHandler.GetReplies, authMiddleware.RequireScope(models.ScopeArticlesRead))
+	articles.GET("/:id/comments/:commentId/history", commentHandler.GetCommentHistory, authMiddleware.RequireScope(models.ScopeArticlesRead))
+	articles.PUT("/:id/comments/:commentId/vote", commentHandler.VoteComment, authMiddleware.RequireScope(models.ScopeCommentsWrite), authMiddleware.RequirePermission(models.PermArticleReact))
+	articles.DELETE("/:id/comments/:commentId/vote", commentHandler.UnvoteComment, authMiddleware.RequireScope(models.ScopeCommentsWrite), authMiddleware.RequirePermission(models.PermArticleReact))
+
+	feed := e.Group("/feed")
+	feed.Use(authMiddleware.AuthMiddleware)
+	feed.GET("", followHandler.GetFeed, authMiddleware.RequireScope(models.ScopeArticlesRead), authMiddleware.RequirePermission(models.PermArticleRead))
+
+	notifications := e.Group("/notifications")
+	notifications.Use(authMiddleware.AuthMiddleware, authMiddleware.RequireSession)
+	notifications.GET("", notificationHandler.GetNotifications)
+	notifications.POST("/:id/read", notificationHandler.MarkRead)
+
+	users := e.Group("/users")
+	users.Use(authMiddleware.AuthMiddleware, authMiddleware.RequireSession)
+	users.GET("/me", userHandler.GetMe)
+	users.PATCH("/me", userHandler.UpdateMe)
+	users.DELETE("/me", userHandler.DeleteMe)
+	users.POST("/me/restore", userHandler.RestoreMe)
+	users.GET("/me/export", userHandler.Export)
+	users.POST("/me/export", userHandler.RequestExport)
+	users.POST("/me/password", userHandler.ChangePassword)
+	users.POST("/me/mfa", userHandler.EnrollMfa)
+	users.POST("/me/mfa/confirm", userHandler.ConfirmMfa)
+	users.DELETE("/me/mfa", userHandler.DisableMfa)
+	users.GET("/me/tokens", userHandler.GetPersonalTokens)
+	users.POST("/me/tokens", userHandler.CreatePersonalToken)
+	users.DELETE("/me/tokens/:tokenId", userHandler.RevokePersonalToken)
+	users.GET("/me/sessions", userHandler.GetSessions)
+	users.DELETE("/me/sessions/:sessionId", userHandler.RevokeSession)
+	users.GET("/:id", userHandler.GetUser)
+	users.PUT("/:id/follow", followHandler.Follow)
+	users.DELETE("/:id/follow", followHandler.Unfollow)
+	users.GET("/:id/followers", followHandler.GetFollowers)
+	users.GET("/:id/following", followHandler.GetFollowing)
+
+	admin := e.Group("/admin")
+	admin.Use(authMiddleware.AuthMiddleware, authMiddleware.RequireSession)
+	admin.GET("/roles", adminHandler.GetRoles, authMiddleware.RequirePermission(models.PermUserRoleUpdate))
+	admin.PUT("/users/:id/role", adminHandler.UpdateRole, authMiddleware.RequirePermission(models.PermUserRoleUpdate))
+	admin.DELETE("/users/:id/lock", adminHandler.UnlockUser, authMiddleware.RequirePermission(models.PermUserUnlock))
 
 	log.Println("Server start")
 	err = e.Start(":" + cfg.Server.Port)
@@ -81,3 +190,44 @@ func (a *App) Run() {
 		panic(err)
 	}
 }
+
+func (a *App) syncRevocations(authService services.AuthServiceInterface, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := authService.SyncRevocations(context.Background())
+		if err != nil {
+			log.Println("Revocation sync failed:", err)
+		}
+	}
+}
+
+func (a *App) runAccountJobs(accountService services.AccountServiceInterface, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := accountService.PurgeAccounts(context.Background())
+			if err != nil {
+				log.Println("Account purge failed:", err)
+			}
+		case <-accountService.Wake():
+		}
+
+		err := accountService.ProcessExports(context.Background())
+		if err != nil {
+			log.Println("Export processing failed:", err)
+		}
+	}
+}
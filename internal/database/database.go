@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"restapp/config"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
@@ -11,7 +12,19 @@ import (
 
 var db *sqlx.DB
 
+// Comment paths are built from zero-padded 10-digit ids joined by "/", so a
+// comment at depth d needs 11*d+10 characters of the path column.
+const (
+	commentPathWidth   = 255
+	commentPathSegment = 11
+	maxCommentDepth    = (commentPathWidth - commentPathSegment + 1) / commentPathSegment
+)
+
 func InitDB(cfg *config.Config) error {
+	if cfg.Comments.MaxDepth > maxCommentDepth {
+		log.Fatalf("comments.max_depth %d exceeds %d, the deepest path the comments table can store", cfg.Comments.MaxDepth, maxCommentDepth)
+	}
+
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=%t",
 		cfg.Database.Username,
 		cfg.Database.Password,
@@ -42,6 +55,11 @@ func InitDB(cfg *config.Config) error {
 		log.Fatal(err)
 	}
 
+	err = migrate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	return nil
 }
 
@@ -56,10 +74,23 @@ func createTables() error {
 			username VARCHAR(255) NOT NULL,
 			password VARCHAR(255) NOT NULL,
 			email VARCHAR(255) NOT NULL,
-			role VARCHAR(255) NOT NULL,
+			role VARCHAR(255) NOT NULL DEFAULT 'author',
+			email_verified_at TIMESTAMP NULL,
+			verification_sent_at TIMESTAMP NULL,
+			token_version INT NOT NULL DEFAULT 0,
+			display_name VARCHAR(100) NULL,
+			bio TEXT NULL,
+			avatar_url VARCHAR(512) NULL,
+			mfa_secret VARCHAR(64) NULL,
+			mfa_enabled_at TIMESTAMP NULL,
+			mfa_last_step BIGINT NOT NULL DEFAULT 0,
+			deletion_scheduled_at TIMESTAMP NULL,
+			deleted_at TIMESTAMP NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-			PRIMARY KEY (id)
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_users_email ((LOWER(email))),
+			UNIQUE KEY uq_users_username ((LOWER(username)))
 		);
 	`)
 	if err != nil {
@@ -72,6 +103,8 @@ func createTables() error {
 			user_id INT NOT NULL,
 			title VARCHAR(255) NOT NULL,
 			content TEXT NOT NULL,
+			likes_count INT NOT NULL DEFAULT 0,
+			comments_count INT NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 			PRIMARY KEY (id),
@@ -87,12 +120,24 @@ func createTables() error {
 			id INT AUTO_INCREMENT,
 			article_id INT NOT NULL,
 			user_id INT NOT NULL,
+			parent_id INT NULL,
+			depth INT NOT NULL DEFAULT 0,
+			path VARCHAR(255) NOT NULL DEFAULT '',
 			content TEXT NOT NULL,
+			edited_at TIMESTAMP NULL,
+			deleted_at TIMESTAMP NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'approved',
+			upvotes INT NOT NULL DEFAULT 0,
+			downvotes INT NOT NULL DEFAULT 0,
+			score DOUBLE NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 			PRIMARY KEY (id),
+			INDEX idx_comments_article_path (article_id, path),
+			INDEX idx_comments_article_score (article_id, score),
 			FOREIGN KEY (article_id) REFERENCES articles(id),
-			FOREIGN KEY (user_id) REFERENCES users(id)
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (parent_id) REFERENCES comments(id)
 		);
 	`)
 	if err != nil {
@@ -100,13 +145,291 @@ func createTables() error {
 	}
 
 	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS likes (
+		CREATE TABLE IF NOT EXISTS comment_edits (
 			id INT AUTO_INCREMENT,
+			comment_id INT NOT NULL,
+			editor_id INT NOT NULL,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (editor_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_votes (
+			id INT AUTO_INCREMENT,
+			comment_id INT NOT NULL,
+			user_id INT NOT NULL,
+			value TINYINT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_comment_votes_comment_user (comment_id, user_id),
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS mentions (
+			id INT AUTO_INCREMENT,
+			source_type VARCHAR(32) NOT NULL,
+			source_id INT NOT NULL,
+			user_id INT NOT NULL,
+			position INT NOT NULL,
+			length INT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			INDEX idx_mentions_source (source_type, source_id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INT AUTO_INCREMENT,
+			user_id INT NOT NULL,
+			actor_id INT NOT NULL,
+			type VARCHAR(32) NOT NULL,
+			source_type VARCHAR(32) NOT NULL,
+			source_id INT NOT NULL,
+			read_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_notifications_source (user_id, type, source_type, source_id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (actor_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_settings (
 			article_id INT NOT NULL,
+			locked BOOLEAN NOT NULL DEFAULT FALSE,
+			audience VARCHAR(16) NOT NULL DEFAULT 'everyone',
+			require_approval BOOLEAN NOT NULL DEFAULT FALSE,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (article_id),
+			FOREIGN KEY (article_id) REFERENCES articles(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id INT AUTO_INCREMENT,
+			user_id INT NOT NULL,
+			family_id CHAR(32) NOT NULL,
+			token_hash CHAR(64) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			rotated_at TIMESTAMP NULL,
+			revoked_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_refresh_tokens_hash (token_hash),
+			INDEX idx_refresh_tokens_family (family_id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti CHAR(32) NOT NULL,
+			user_id INT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (jti),
+			INDEX idx_revoked_tokens_expires (expires_at),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS password_resets (
+			id INT AUTO_INCREMENT,
+			user_id INT NOT NULL,
+			token_hash CHAR(64) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_password_resets_hash (token_hash),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS login_attempts (
+			scope VARCHAR(16) NOT NULL,
+			attempt_key VARCHAR(255) NOT NULL,
+			failures INT NOT NULL DEFAULT 0,
+			last_failed_at TIMESTAMP NULL,
+			locked_until TIMESTAMP NULL,
+			PRIMARY KEY (scope, attempt_key)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+			id INT AUTO_INCREMENT,
 			user_id INT NOT NULL,
+			code_hash CHAR(64) NOT NULL,
+			used_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_mfa_recovery_codes (user_id, code_hash),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS oidc_states (
+			state VARCHAR(64) NOT NULL,
+			provider VARCHAR(64) NOT NULL,
+			code_verifier VARCHAR(128) NOT NULL,
+			nonce VARCHAR(64) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (state)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_identities (
+			id INT AUTO_INCREMENT,
+			user_id INT NOT NULL,
+			provider VARCHAR(64) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			email VARCHAR(255) NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_user_identities (provider, subject),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS personal_access_tokens (
+			id INT AUTO_INCREMENT,
+			user_id INT NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			token_prefix VARCHAR(16) NOT NULL,
+			token_hash CHAR(64) NOT NULL,
+			scopes VARCHAR(255) NOT NULL,
+			token_version INT NOT NULL DEFAULT 0,
+			expires_at TIMESTAMP NULL,
+			last_used_at TIMESTAMP NULL,
+			revoked_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uq_personal_access_tokens_hash (token_hash),
+			INDEX idx_personal_access_tokens_user (user_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS follows (
+			follower_id INT NOT NULL,
+			followee_id INT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (follower_id, followee_id),
+			INDEX idx_follows_followee (followee_id),
+			FOREIGN KEY (follower_id) REFERENCES users(id),
+			FOREIGN KEY (followee_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS data_exports (
+			id INT AUTO_INCREMENT,
+			user_id INT NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			file_path VARCHAR(512) NULL,
+			error TEXT NULL,
+			started_at TIMESTAMP NULL,
+			completed_at TIMESTAMP NULL,
+			expires_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			INDEX idx_data_exports_status (status),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS sessions (
+			id CHAR(32) NOT NULL,
+			user_id INT NOT NULL,
+			user_agent VARCHAR(512) NOT NULL DEFAULT '',
+			ip VARCHAR(45) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP NULL,
+			PRIMARY KEY (id),
+			INDEX idx_sessions_user (user_id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS reactions (
+			id INT AUTO_INCREMENT,
+			article_id INT NOT NULL,
+			user_id INT NOT NULL,
+			type VARCHAR(32) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 			PRIMARY KEY (id),
+			UNIQUE KEY uq_reactions_article_user_type (article_id, user_id, type),
 			FOREIGN KEY (article_id) REFERENCES articles(id),
 			FOREIGN KEY (user_id) REFERENCES users(id)
 		)
@@ -117,3 +440,487 @@ func createTables() error {
 
 	return nil
 }
+
+func migrate() error {
+	err := migrateLikesToReactions()
+	if err != nil {
+		return err
+	}
+
+	err = addArticleCounters()
+	if err != nil {
+		return err
+	}
+
+	err = addCommentThreads()
+	if err != nil {
+		return err
+	}
+
+	err = addCommentEditMarkers()
+	if err != nil {
+		return err
+	}
+
+	err = addCommentScores()
+	if err != nil {
+		return err
+	}
+
+	err = addColumnIfMissing("comments", "status", "VARCHAR(16) NOT NULL DEFAULT 'approved' AFTER deleted_at")
+	if err != nil {
+		return err
+	}
+
+	err = addEmailVerification()
+	if err != nil {
+		return err
+	}
+
+	err = addColumnIfMissing("users", "token_version", "INT NOT NULL DEFAULT 0 AFTER verification_sent_at")
+	if err != nil {
+		return err
+	}
+
+	err = addUserProfiles()
+	if err != nil {
+		return err
+	}
+
+	err = addMfa()
+	if err != nil {
+		return err
+	}
+
+	err = addAccountDeletion()
+	if err != nil {
+		return err
+	}
+
+	err = addDefaultRole()
+	if err != nil {
+		return err
+	}
+
+	err = addUniqueUserIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = backfillSessions()
+	if err != nil {
+		return err
+	}
+
+	err = addPersonalTokenVersions()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func migrateLikesToReactions() error {
+	exists, err := tableExists("likes")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		INSERT IGNORE INTO reactions (article_id, user_id, type, created_at, updated_at)
+		SELECT article_id, user_id, 'like', created_at, updated_at FROM likes
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DROP TABLE likes`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addArticleCounters() error {
+	exists, err := columnExists("articles", "likes_count")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE articles
+			ADD COLUMN likes_count INT NOT NULL DEFAULT 0,
+			ADD COLUMN comments_count INT NOT NULL DEFAULT 0
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE articles a
+		SET a.likes_count = (SELECT COUNT(*) FROM reactions r WHERE r.article_id = a.id AND r.type = 'like'),
+			a.comments_count = (SELECT COUNT(*) FROM comments c WHERE c.article_id = a.id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addCommentThreads() error {
+	exists, err := columnExists("comments", "parent_id")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE comments
+			ADD COLUMN parent_id INT NULL AFTER user_id,
+			ADD COLUMN depth INT NOT NULL DEFAULT 0 AFTER parent_id,
+			ADD COLUMN path VARCHAR(255) NOT NULL DEFAULT '' AFTER depth,
+			ADD INDEX idx_comments_article_path (article_id, path),
+			ADD FOREIGN KEY (parent_id) REFERENCES comments(id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE comments SET path = LPAD(id, 10, '0')`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addCommentEditMarkers() error {
+	exists, err := columnExists("comments", "edited_at")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE comments
+			ADD COLUMN edited_at TIMESTAMP NULL AFTER content,
+			ADD COLUMN deleted_at TIMESTAMP NULL AFTER edited_at
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addCommentScores() error {
+	exists, err := columnExists("comments", "score")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE comments
+			ADD COLUMN upvotes INT NOT NULL DEFAULT 0 AFTER deleted_at,
+			ADD COLUMN downvotes INT NOT NULL DEFAULT 0 AFTER upvotes,
+			ADD COLUMN score DOUBLE NOT NULL DEFAULT 0 AFTER downvotes,
+			ADD INDEX idx_comments_article_score (article_id, score)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addUserProfiles() error {
+	err := addColumnIfMissing("users", "display_name", "VARCHAR(100) NULL AFTER token_version")
+	if err != nil {
+		return err
+	}
+
+	err = addColumnIfMissing("users", "bio", "TEXT NULL AFTER display_name")
+	if err != nil {
+		return err
+	}
+
+	return addColumnIfMissing("users", "avatar_url", "VARCHAR(512) NULL AFTER bio")
+}
+
+// addDefaultRole makes author the default role and promotes existing users,
+// who could publish before roles were introduced. The column default marks
+// the migration as done so later demotions to user are left alone.
+func addDefaultRole() error {
+	var roleDefault *string
+	err := db.Get(&roleDefault, `
+		SELECT column_default FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'role'
+	`)
+	if err != nil {
+		return err
+	}
+	if roleDefault != nil {
+		return nil
+	}
+
+	_, err = db.Exec(`UPDATE users SET role = 'author' WHERE role = 'user' AND deleted_at IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE users ALTER COLUMN role SET DEFAULT 'author'`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addEmailVerification() error {
+	exists, err := columnExists("users", "verification_sent_at")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	err = addColumnIfMissing("users", "email_verified_at", "TIMESTAMP NULL AFTER role")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE users ADD COLUMN verification_sent_at TIMESTAMP NULL AFTER email_verified_at`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addMfa() error {
+	exists, err := columnExists("users", "mfa_secret")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE users
+			ADD COLUMN mfa_secret VARCHAR(64) NULL AFTER avatar_url,
+			ADD COLUMN mfa_enabled_at TIMESTAMP NULL AFTER mfa_secret,
+			ADD COLUMN mfa_last_step BIGINT NOT NULL DEFAULT 0 AFTER mfa_enabled_at
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addAccountDeletion() error {
+	exists, err := columnExists("users", "deletion_scheduled_at")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE users
+			ADD COLUMN deletion_scheduled_at TIMESTAMP NULL AFTER mfa_last_step,
+			ADD COLUMN deleted_at TIMESTAMP NULL AFTER deletion_scheduled_at
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addUniqueUserIndexes() error {
+	exists, err := indexExists("users", "uq_users_email")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// Duplicates are left for an operator to resolve; merging or renaming
+	// real accounts is not something a startup migration should decide.
+	var conflicts []string
+	for _, column := range []string{"email", "username"} {
+		duplicates, err := findDuplicateUsers(column)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, duplicates...)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("cannot add unique user indexes, resolve the conflicting users first: %s", strings.Join(conflicts, "; "))
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE users
+			ADD UNIQUE KEY uq_users_email ((LOWER(email))),
+			ADD UNIQUE KEY uq_users_username ((LOWER(username)))
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// findDuplicateUsers describes every group of users sharing the same
+// case-insensitive value of column, e.g. "email alice@example.com: ids 3,17".
+func findDuplicateUsers(column string) ([]string, error) {
+	var rows []struct {
+		Value string `db:"value"`
+		Ids   string `db:"ids"`
+	}
+	err := db.Select(&rows, fmt.Sprintf(`
+		SELECT LOWER(%[1]s) AS value, GROUP_CONCAT(id ORDER BY id) AS ids
+		FROM users
+		GROUP BY LOWER(%[1]s)
+		HAVING COUNT(*) > 1
+	`, column))
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]string, 0, len(rows))
+	for _, row := range rows {
+		duplicates = append(duplicates, fmt.Sprintf("%s %s: ids %s", column, row.Value, row.Ids))
+	}
+	return duplicates, nil
+}
+
+func backfillSessions() error {
+	_, err := db.Exec(`
+		INSERT IGNORE INTO sessions (id, user_id, created_at, last_seen_at)
+		SELECT family_id, MIN(user_id), MIN(created_at), MAX(created_at)
+		FROM refresh_tokens
+		WHERE revoked_at IS NULL AND rotated_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		GROUP BY family_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addPersonalTokenVersions() error {
+	exists, err := columnExists("personal_access_tokens", "token_version")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(`ALTER TABLE personal_access_tokens ADD COLUMN token_version INT NOT NULL DEFAULT 0 AFTER scopes`)
+	if err != nil {
+		return err
+	}
+
+	// Existing tokens stay valid until the owner's next password reset or
+	// "log out everywhere".
+	_, err = db.Exec(`
+		UPDATE personal_access_tokens t
+		JOIN users u ON u.id = t.user_id
+		SET t.token_version = u.token_version
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addColumnIfMissing(table, column, definition string) error {
+	exists, err := columnExists(table, column)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func tableExists(name string) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = DATABASE() AND table_name = ?
+		)
+	`, name)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func indexExists(table, index string) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.statistics
+			WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?
+		)
+	`, table, index)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func columnExists(table, column string) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
+		)
+	`, table, column)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/response"
+	"restapp/internal/services"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminHandler struct {
+	AuthService services.AuthServiceInterface
+}
+
+func NewAdminHandler(authService services.AuthServiceInterface) *AdminHandler {
+	return &AdminHandler{AuthService: authService}
+}
+
+func (h *AdminHandler) GetRoles(c echo.Context) error {
+	roles := make(map[string][]string, len(models.Roles))
+	for _, role := range models.Roles {
+		roles[role] = models.RolePermissions(role)
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: roles,
+	})
+}
+
+func (h *AdminHandler) UpdateRole(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidUserID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.RoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	user, err := h.AuthService.UpdateRole(ctx, claims.UserId, userId, req.Role)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrMfaRequired) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrMfaRequired.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrInvalidRole) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidRole.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    user,
+		Message: messages.MsgRoleUpdated,
+	})
+}
+
+func (h *AdminHandler) UnlockUser(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidUserID,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.AuthService.UnlockUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgUserUnlocked,
+	})
+}
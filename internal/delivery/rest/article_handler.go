@@ -13,13 +13,13 @@ import (
 )
 
 type ArticleHandler struct {
-	ArticleService services.ArticleServiceInterface
-	AuthService    services.AuthServiceInterface
-	CommentService services.CommentServiceInterface
+	ArticleService      services.ArticleServiceInterface
+	CommentService      services.CommentServiceInterface
+	InlineCommentsLimit int
 }
 
-func NewArticleHandler(articleService services.ArticleServiceInterface, authService services.AuthServiceInterface, commentService services.CommentServiceInterface) *ArticleHandler {
-	return &ArticleHandler{ArticleService: articleService, AuthService: authService, CommentService: commentService}
+func NewArticleHandler(articleService services.ArticleServiceInterface, commentService services.CommentServiceInterface, inlineCommentsLimit int) *ArticleHandler {
+	return &ArticleHandler{ArticleService: articleService, CommentService: commentService, InlineCommentsLimit: inlineCommentsLimit}
 }
 
 func (h *ArticleHandler) GetAllArticles(c echo.Context) error {
@@ -60,16 +60,25 @@ func (h *ArticleHandler) GetById(c echo.Context) error {
 		})
 	}
 
-	comments, err := h.CommentService.GetAllComments(ctx, id)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: messages.ErrDatabaseOperation,
-			Error:   err.Error(),
+	if c.QueryParam("include") == "comments" {
+		viewerId, _ := c.Get("user_id").(int)
+		comments, err := h.CommentService.GetThreads(ctx, id, models.CommentQuery{
+			Sort:       models.CommentSortOldest,
+			Limit:      h.InlineCommentsLimit,
+			MaxReplies: h.InlineCommentsLimit,
+			ViewerId:   viewerId,
 		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: messages.ErrDatabaseOperation,
+				Error:   err.Error(),
+			})
+		}
+
+		article.Comments = comments.Comments
 	}
 
-	article.Comments = *comments
 	return c.JSON(http.StatusOK, response.SuccessResponse{
 		Data:    article,
 		Message: "",
@@ -81,7 +90,7 @@ func (h *ArticleHandler) StoreArticle(c echo.Context) error {
 
 	var articleRequest models.ArticleRequest
 
-	claims, err := h.AuthService.ValidateToken(h.AuthService.FormatToken(c.Request().Header.Get("Authorization")))
+	claims, err := currentClaims(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -107,6 +116,14 @@ func (h *ArticleHandler) StoreArticle(c echo.Context) error {
 	}
 
 	if err := h.ArticleService.CreateArticle(ctx, &articleRequest, claims.UserId); err != nil {
+		if errors.Is(err, messages.ErrEmailNotVerified) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrEmailNotVerified.Error(),
+				Error:   err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: messages.ErrDatabaseOperation,
@@ -131,6 +148,15 @@ func (h *ArticleHandler) UpdateArticle(c echo.Context) error {
 		})
 	}
 
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
 	var articleRequest models.ArticleRequest
 	if err := c.Bind(&articleRequest); err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
@@ -148,7 +174,15 @@ func (h *ArticleHandler) UpdateArticle(c echo.Context) error {
 		})
 	}
 
-	if err := h.ArticleService.UpdateArticle(ctx, id, &articleRequest); err != nil {
+	if err := h.ArticleService.UpdateArticle(ctx, id, &articleRequest, claims.UserId, claims.Role); err != nil {
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
 			Code:    http.StatusNotFound,
 			Message: messages.ErrArticleNotFound,
@@ -173,7 +207,32 @@ func (h *ArticleHandler) DeleteArticle(c echo.Context) error {
 		})
 	}
 
-	if err := h.ArticleService.DeleteArticle(ctx, id); err != nil {
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.ArticleService.DeleteArticle(ctx, id, claims.UserId, claims.Role); err != nil {
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: messages.ErrDatabaseOperation,
@@ -196,7 +255,7 @@ func (h *ArticleHandler) LikeArticle(c echo.Context) error {
 		})
 	}
 
-	claims, err := h.AuthService.ValidateToken(h.AuthService.FormatToken(c.Request().Header.Get("Authorization")))
+	claims, err := currentClaims(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -205,11 +264,12 @@ func (h *ArticleHandler) LikeArticle(c echo.Context) error {
 		})
 	}
 
-	if err := h.ArticleService.LikeArticle(ctx, id, claims.UserId); err != nil {
-		if errors.Is(err, messages.ErrLikeExists) {
-			return c.JSON(http.StatusConflict, response.ErrorResponse{
-				Code:    http.StatusConflict,
-				Message: messages.ErrLikeExists.Error(),
+	count, err := h.ArticleService.LikeArticle(ctx, id, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
 				Error:   err.Error(),
 			})
 		}
@@ -222,6 +282,7 @@ func (h *ArticleHandler) LikeArticle(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    map[string]int{"likes_count": count},
 		Message: messages.MsgArticleLiked,
 	})
 }
@@ -238,7 +299,7 @@ func (h *ArticleHandler) UnlikeArticle(c echo.Context) error {
 		})
 	}
 
-	claims, err := h.AuthService.ValidateToken(h.AuthService.FormatToken(c.Request().Header.Get("Authorization")))
+	claims, err := currentClaims(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -247,7 +308,16 @@ func (h *ArticleHandler) UnlikeArticle(c echo.Context) error {
 		})
 	}
 
-	if err := h.ArticleService.UnlikeArticle(ctx, id, claims.UserId); err != nil {
+	count, err := h.ArticleService.UnlikeArticle(ctx, id, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: messages.ErrDatabaseOperation,
@@ -256,6 +326,119 @@ func (h *ArticleHandler) UnlikeArticle(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    map[string]int{"likes_count": count},
 		Message: messages.MsgArticleUnliked,
 	})
 }
+
+func (h *ArticleHandler) React(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	reactionType := c.Param("type")
+	count, err := h.ArticleService.React(ctx, id, claims.UserId, reactionType)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidReaction) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidReaction.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: map[string]interface{}{
+			"type":  reactionType,
+			"count": count,
+		},
+		Message: messages.MsgReactionAdded,
+	})
+}
+
+func (h *ArticleHandler) Unreact(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	reactionType := c.Param("type")
+	count, err := h.ArticleService.Unreact(ctx, id, claims.UserId, reactionType)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidReaction) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidReaction.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: map[string]interface{}{
+			"type":  reactionType,
+			"count": count,
+		},
+		Message: messages.MsgReactionRemoved,
+	})
+}
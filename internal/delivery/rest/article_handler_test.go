@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"restapp/config"
+	"restapp/internal/database"
+	"restapp/internal/middlewares"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"restapp/internal/services"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// stubAuth authenticates every request as a fixed user; the remaining
+// AuthServiceInterface methods are left unimplemented.
+type stubAuth struct {
+	services.AuthServiceInterface
+	userId int
+}
+
+func (s *stubAuth) ValidateToken(tokenString string) (*models.Claims, error) {
+	return &models.Claims{UserId: s.userId, Role: models.RoleUser}, nil
+}
+
+func (s *stubAuth) TouchSession(ctx context.Context, claims *models.Claims) error {
+	return nil
+}
+
+// testDB connects to the MySQL database described by the TEST_DB_* variables
+// and skips the test when TEST_DB_HOST is not set.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	if os.Getenv("TEST_DB_HOST") == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+
+	cfg := &config.Config{}
+	cfg.Database.Host = os.Getenv("TEST_DB_HOST")
+	cfg.Database.Port = envOr("TEST_DB_PORT", "3306")
+	cfg.Database.Username = envOr("TEST_DB_USER", "root")
+	cfg.Database.Password = os.Getenv("TEST_DB_PASSWORD")
+	cfg.Database.DBName = envOr("TEST_DB_NAME", "restapp_test")
+	cfg.Database.ParseTime = true
+
+	err := database.InitDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return database.GetDB()
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func createLikeFixture(t *testing.T, db *sqlx.DB) (userId int, articleId int) {
+	t.Helper()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	result, err := db.Exec(
+		`INSERT INTO users (username, password, email, role) VALUES (?, '', ?, ?)`,
+		"liker-"+suffix,
+		"liker-"+suffix+"@example.com",
+		models.RoleUser,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := result.LastInsertId()
+	userId = int(id)
+
+	result, err = db.Exec(
+		`INSERT INTO articles (user_id, title, content) VALUES (?, 'Concurrent likes', 'Body')`,
+		userId,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ = result.LastInsertId()
+	articleId = int(id)
+
+	return userId, articleId
+}
+
+func TestLikeArticleConcurrent(t *testing.T) {
+	db := testDB(t)
+	userId, articleId := createLikeFixture(t, db)
+
+	cfg := &config.Config{}
+	articleService := services.NewArticleService(repositories.NewArticleRepository(db), repositories.NewUserRepository(db), nil, cfg)
+	handler := NewArticleHandler(articleService, nil, 0)
+	auth := middlewares.NewAuthMiddleware(&stubAuth{userId: userId})
+
+	e := echo.New()
+	e.PUT("/articles/:id/like", handler.LikeArticle, auth.AuthMiddleware)
+	e.DELETE("/articles/:id/like", handler.UnlikeArticle, auth.AuthMiddleware)
+
+	assertLikes := func(method string, wantRows, wantCount int) {
+		t.Helper()
+
+		const workers = 16
+		statuses := make([]int, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				req := httptest.NewRequest(method, fmt.Sprintf("/articles/%d/like", articleId), nil)
+				req.Header.Set("Authorization", "Bearer test")
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+				statuses[i] = rec.Code
+			}(i)
+		}
+		wg.Wait()
+
+		for _, status := range statuses {
+			if status != http.StatusOK {
+				t.Fatalf("%s returned %d, want %d", method, status, http.StatusOK)
+			}
+		}
+
+		var rows int
+		err := db.Get(&rows, `SELECT COUNT(*) FROM reactions WHERE article_id = ? AND user_id = ? AND type = ?`, articleId, userId, models.ReactionLike)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows != wantRows {
+			t.Errorf("after concurrent %s: %d reaction rows, want %d", method, rows, wantRows)
+		}
+
+		var likesCount int
+		err = db.Get(&likesCount, `SELECT likes_count FROM articles WHERE id = ?`, articleId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if likesCount != wantCount {
+			t.Errorf("after concurrent %s: likes_count = %d, want %d", method, likesCount, wantCount)
+		}
+	}
+
+	assertLikes(http.MethodPut, 1, 1)
+	assertLikes(http.MethodDelete, 0, 0)
+}
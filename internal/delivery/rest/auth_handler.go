@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
 	"restapp/internal/messages"
 	"restapp/internal/models"
@@ -38,8 +39,33 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		})
 	}
 
-	userData, err := h.AuthService.Register(ctx, &req)
+	userData, err := h.AuthService.Register(ctx, &req, clientInfo(c))
 	if err != nil {
+		if errors.Is(err, messages.ErrEmailAlreadyExists) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrEmailAlreadyExists.Error(),
+				Error:   err.Error(),
+				Field:   "email",
+			})
+		}
+		if errors.Is(err, messages.ErrUsernameAlreadyExists) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrUsernameAlreadyExists.Error(),
+				Error:   err.Error(),
+				Field:   "username",
+			})
+		}
+		if errors.Is(err, messages.ErrUsernameReserved) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrValidationFailed,
+				Error:   err.Error(),
+				Field:   "username",
+			})
+		}
+
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			Code:    http.StatusBadRequest,
 			Message: messages.ErrBadRequest,
@@ -73,18 +99,347 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		})
 	}
 
-	token, err := h.AuthService.Login(ctx, &req)
+	tokens, challenge, err := h.AuthService.Login(ctx, &req, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, messages.ErrTooManyAttempts) {
+			return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: messages.ErrTooManyAttempts.Error(),
+				Error:   messages.ErrTooManyAttempts.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrInvalidCredentials) {
+			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: messages.ErrInvalidCredentials,
+				Error:   messages.ErrInvalidCredentials.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	if challenge != nil {
+		return c.JSON(http.StatusOK, response.SuccessResponse{
+			Data:    challenge,
+			Message: messages.MsgMfaRequired,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: tokens,
+	})
+}
+
+func (h *AuthHandler) VerifyMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req models.MfaVerifyRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	tokens, err := h.AuthService.VerifyMfa(ctx, req.MfaToken, req.Code, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, messages.ErrTooManyAttempts) {
+			return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: messages.ErrTooManyAttempts.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrInvalidMfaCode) || errors.Is(err, messages.ErrInvalidToken) || errors.Is(err, messages.ErrParsingToken) || errors.Is(err, messages.ErrMfaNotEnabled) {
+			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: err.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: tokens,
+	})
+}
+
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req models.RefreshRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	tokens, err := h.AuthService.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidRefreshToken) || errors.Is(err, messages.ErrRefreshTokenExpired) || errors.Is(err, messages.ErrRefreshTokenReused) {
+			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: err.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    tokens,
+		Message: messages.MsgTokenRefreshed,
+	})
+}
+
+func (h *AuthHandler) Logout(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req models.LogoutRequest
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrBadRequest,
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	err = h.AuthService.Logout(ctx, claims, &req)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: messages.ErrInvalidCredentials,
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
 			Error:   err.Error(),
 		})
 	}
 
+	message := messages.MsgLoggedOut
+	if req.All {
+		message = messages.MsgLoggedOutEverywhere
+	}
+
 	return c.JSON(http.StatusOK, response.SuccessResponse{
-		Data: map[string]string{
-			"token": token,
-		},
+		Message: message,
 	})
 }
+
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req models.ForgotPasswordRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	err := h.AuthService.ForgotPassword(ctx, req.Email)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, response.SuccessResponse{
+		Message: messages.MsgPasswordResetSent,
+	})
+}
+
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req models.ResetPasswordRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	err := h.AuthService.ResetPassword(ctx, req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidResetToken) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidResetToken.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgPasswordReset,
+	})
+}
+
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidVerification.Error(),
+			Error:   messages.ErrInvalidVerification.Error(),
+		})
+	}
+
+	err := h.AuthService.VerifyEmail(ctx, token)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidVerification) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidVerification.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgEmailVerified,
+	})
+}
+
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.AuthService.ResendVerification(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrAlreadyVerified) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrAlreadyVerified.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrVerificationThrottled) {
+			return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: messages.ErrVerificationThrottled.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, response.SuccessResponse{
+		Message: messages.MsgVerificationSent,
+	})
+}
+
+func (h *AuthHandler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.AuthService.JWKS())
+}
+
+func clientInfo(c echo.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: c.Request().UserAgent(),
+		IP:        c.RealIP(),
+	}
+}
+
+// currentClaims returns the claims AuthMiddleware validated for this request.
+func currentClaims(c echo.Context) (*models.Claims, error) {
+	claims, ok := c.Get("claims").(*models.Claims)
+	if !ok {
+		return nil, messages.ErrMissingToken
+	}
+	return claims, nil
+}
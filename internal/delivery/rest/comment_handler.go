@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"restapp/internal/messages"
@@ -12,13 +14,11 @@ import (
 
 type CommentHandler struct {
 	CommentService services.CommentServiceInterface
-	AuthService    services.AuthServiceInterface
 }
 
-func NewCommentHandler(commentService services.CommentServiceInterface, authService services.AuthServiceInterface) *CommentHandler {
+func NewCommentHandler(commentService services.CommentServiceInterface) *CommentHandler {
 	return &CommentHandler{
 		CommentService: commentService,
-		AuthService:    authService,
 	}
 }
 
@@ -52,7 +52,7 @@ func (h *CommentHandler) CreateComment(c echo.Context) error {
 		})
 	}
 
-	claims, err := h.AuthService.ValidateToken(h.AuthService.FormatToken(c.Request().Header.Get("Authorization")))
+	claims, err := currentClaims(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -61,7 +61,40 @@ func (h *CommentHandler) CreateComment(c echo.Context) error {
 		})
 	}
 
-	if _, err := h.CommentService.CreateComment(ctx, &req, intArticleId, claims.UserId); err != nil {
+	comment, err := h.CommentService.CreateComment(ctx, &req, intArticleId, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrCommentsLocked) || errors.Is(err, messages.ErrFollowersOnly) || errors.Is(err, messages.ErrVerifiedOnly) || errors.Is(err, messages.ErrEmailNotVerified) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: err.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrParentMismatch) || errors.Is(err, messages.ErrCommentTooDeep) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrBadRequest,
+				Error:   err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: messages.ErrDatabaseOperation,
@@ -69,11 +102,73 @@ func (h *CommentHandler) CreateComment(c echo.Context) error {
 		})
 	}
 
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/articles/%d/comments/%d", intArticleId, comment.Id))
+
+	if comment.Status == models.CommentStatusPending {
+		return c.JSON(http.StatusAccepted, response.SuccessResponse{
+			Data:    comment,
+			Message: messages.MsgCommentPending,
+		})
+	}
+
 	return c.JSON(http.StatusCreated, response.SuccessResponse{
+		Data:    comment,
 		Message: messages.MsgCommentCreated,
 	})
 }
 
+func (h *CommentHandler) GetComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	comment, err := h.CommentService.GetComment(ctx, articleId, commentId, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: comment,
+	})
+}
+
 func (h *CommentHandler) GetAllComments(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -87,8 +182,575 @@ func (h *CommentHandler) GetAllComments(c echo.Context) error {
 		})
 	}
 
-	comments, err := h.CommentService.GetAllComments(ctx, intArticleId)
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	query := models.CommentQuery{
+		Sort:     c.QueryParam("sort"),
+		Cursor:   c.QueryParam("cursor"),
+		Flat:     c.QueryParam("view") == "flat",
+		ViewerId: claims.UserId,
+	}
+	if c.QueryParam("limit") != "" {
+		query.Limit, err = strconv.Atoi(c.QueryParam("limit"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrBadRequest,
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	comments, err := h.CommentService.GetThreads(ctx, intArticleId, query)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+		if errors.Is(err, messages.ErrInvalidSort) || errors.Is(err, messages.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrBadRequest,
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: comments,
+	})
+}
+
+func (h *CommentHandler) GetReplies(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	replies, err := h.CommentService.GetReplies(ctx, articleId, commentId, claims.UserId, c.QueryParam("view") == "flat")
+	if err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: replies,
+	})
+}
+
+func (h *CommentHandler) UpdateComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.CommentRequest
+	if err = c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err = req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	comment, err := h.CommentService.UpdateComment(ctx, &req, articleId, commentId, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    comment,
+		Message: messages.MsgCommentUpdated,
+	})
+}
+
+func (h *CommentHandler) DeleteComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.CommentService.DeleteComment(ctx, articleId, commentId, claims.UserId); err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgCommentDeleted,
+	})
+}
+
+func (h *CommentHandler) GetCommentHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	edits, err := h.CommentService.GetCommentHistory(ctx, articleId, commentId, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: edits,
+	})
+}
+
+func (h *CommentHandler) VoteComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.CommentVoteRequest
+	if err = c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err = req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	comment, err := h.CommentService.Vote(ctx, articleId, commentId, claims.UserId, req.Value)
+	if err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    comment,
+		Message: messages.MsgCommentVoted,
+	})
+}
+
+func (h *CommentHandler) UnvoteComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	comment, err := h.CommentService.Vote(ctx, articleId, commentId, claims.UserId, 0)
+	if err != nil {
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    comment,
+		Message: messages.MsgCommentUnvoted,
+	})
+}
+
+func (h *CommentHandler) GetSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	settings, err := h.CommentService.GetSettings(ctx, articleId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: settings,
+	})
+}
+
+func (h *CommentHandler) UpdateSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.CommentSettingsRequest
+	if err = c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err = req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	settings, err := h.CommentService.UpdateSettings(ctx, &req, articleId, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    settings,
+		Message: messages.MsgSettingsUpdated,
+	})
+}
+
+func (h *CommentHandler) GetPendingComments(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	comments, err := h.CommentService.GetPendingComments(ctx, articleId, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: messages.ErrDatabaseOperation,
@@ -100,3 +762,83 @@ func (h *CommentHandler) GetAllComments(c echo.Context) error {
 		Data: comments,
 	})
 }
+
+func (h *CommentHandler) ApproveComment(c echo.Context) error {
+	return h.moderateComment(c, true)
+}
+
+func (h *CommentHandler) RejectComment(c echo.Context) error {
+	return h.moderateComment(c, false)
+}
+
+func (h *CommentHandler) moderateComment(c echo.Context, approve bool) error {
+	ctx := c.Request().Context()
+
+	articleId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidArticleID,
+			Error:   err.Error(),
+		})
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidCommentID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.CommentService.ModerateComment(ctx, articleId, commentId, claims.UserId, approve); err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrArticleNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrCommentNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrCommentNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrForbidden.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	message := messages.MsgCommentRejected
+	if approve {
+		message = messages.MsgCommentApproved
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: message,
+	})
+}
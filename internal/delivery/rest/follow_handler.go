@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/response"
+	"restapp/internal/services"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FollowHandler struct {
+	FollowService services.FollowServiceInterface
+	FeedService   services.FeedServiceInterface
+}
+
+func NewFollowHandler(followService services.FollowServiceInterface, feedService services.FeedServiceInterface) *FollowHandler {
+	return &FollowHandler{
+		FollowService: followService,
+		FeedService:   feedService,
+	}
+}
+
+func (h *FollowHandler) Follow(c echo.Context) error {
+	ctx := c.Request().Context()
+	userId, _ := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidUserID,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.FollowService.Follow(ctx, userId, id)
+	if err != nil {
+		if errors.Is(err, messages.ErrCannotFollowSelf) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrCannotFollowSelf.Error(),
+				Error:   err.Error(),
+			})
+		}
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrFollowing,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgFollowed,
+	})
+}
+
+func (h *FollowHandler) Unfollow(c echo.Context) error {
+	ctx := c.Request().Context()
+	userId, _ := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidUserID,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.FollowService.Unfollow(ctx, userId, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrFollowing,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgUnfollowed,
+	})
+}
+
+func (h *FollowHandler) GetFollowers(c echo.Context) error {
+	return h.list(c, h.FollowService.GetFollowers)
+}
+
+func (h *FollowHandler) GetFollowing(c echo.Context) error {
+	return h.list(c, h.FollowService.GetFollowing)
+}
+
+func (h *FollowHandler) list(c echo.Context, fetch func(ctx context.Context, userId int, cursor string, limit int) (*models.FollowList, error)) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidUserID,
+			Error:   err.Error(),
+		})
+	}
+
+	limit, err := queryLimit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	list, err := fetch(ctx, id, c.QueryParam("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrBadRequest,
+				Error:   err.Error(),
+			})
+		}
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: list,
+	})
+}
+
+func (h *FollowHandler) GetFeed(c echo.Context) error {
+	ctx := c.Request().Context()
+	userId, _ := c.Get("user_id").(int)
+
+	limit, err := queryLimit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	feed, err := h.FeedService.GetFeed(ctx, userId, c.QueryParam("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, messages.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrBadRequest,
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrGettingFeed,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: feed,
+	})
+}
+
+func queryLimit(c echo.Context) (int, error) {
+	if c.QueryParam("limit") == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(c.QueryParam("limit"))
+}
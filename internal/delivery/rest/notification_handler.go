@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"restapp/internal/messages"
+	"restapp/internal/response"
+	"restapp/internal/services"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	NotificationService services.NotificationServiceInterface
+}
+
+func NewNotificationHandler(notificationService services.NotificationServiceInterface) *NotificationHandler {
+	return &NotificationHandler{
+		NotificationService: notificationService,
+	}
+}
+
+func (h *NotificationHandler) GetNotifications(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	notifications, err := h.NotificationService.GetNotifications(ctx, claims.UserId, c.QueryParam("unread") == "true")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: notifications,
+	})
+}
+
+func (h *NotificationHandler) MarkRead(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidNotificationID,
+			Error:   err.Error(),
+		})
+	}
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.NotificationService.MarkRead(ctx, id, claims.UserId); err != nil {
+		if errors.Is(err, messages.ErrNotificationNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrNotificationNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDatabaseOperation,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgNotificationRead,
+	})
+}
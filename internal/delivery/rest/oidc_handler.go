@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"restapp/internal/messages"
+	"restapp/internal/response"
+	"restapp/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OIDCHandler struct {
+	OIDCService services.OIDCServiceInterface
+}
+
+func NewOIDCHandler(oidcService services.OIDCServiceInterface) *OIDCHandler {
+	return &OIDCHandler{OIDCService: oidcService}
+}
+
+func (h *OIDCHandler) Login(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	authURL, err := h.OIDCService.AuthURL(ctx, c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, messages.ErrUnknownProvider) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUnknownProvider.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusBadGateway, response.ErrorResponse{
+			Code:    http.StatusBadGateway,
+			Message: messages.ErrOIDCExchange.Error(),
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+func (h *OIDCHandler) Callback(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if providerError := c.QueryParam("error"); providerError != "" {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: messages.ErrOIDCExchange.Error(),
+			Error:   providerError,
+		})
+	}
+
+	tokens, challenge, err := h.OIDCService.Callback(ctx, c.Param("provider"), c.QueryParam("state"), c.QueryParam("code"), clientInfo(c))
+	if err != nil {
+		if errors.Is(err, messages.ErrUnknownProvider) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUnknownProvider.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrInvalidOIDCState) || errors.Is(err, messages.ErrOIDCEmailMissing) || errors.Is(err, messages.ErrOIDCEmailUnverified) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrOIDCAccountUnverified) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrOIDCAccountUnverified.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrOIDCExchange) {
+			return c.JSON(http.StatusBadGateway, response.ErrorResponse{
+				Code:    http.StatusBadGateway,
+				Message: messages.ErrOIDCExchange.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	if challenge != nil {
+		return c.JSON(http.StatusOK, response.SuccessResponse{
+			Data:    challenge,
+			Message: messages.MsgMfaRequired,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: tokens,
+	})
+}
@@ -0,0 +1,656 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/response"
+	"restapp/internal/services"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type UserHandler struct {
+	UserService    services.UserServiceInterface
+	AuthService    services.AuthServiceInterface
+	AccountService services.AccountServiceInterface
+}
+
+func NewUserHandler(userService services.UserServiceInterface, authService services.AuthServiceInterface, accountService services.AccountServiceInterface) *UserHandler {
+	return &UserHandler{UserService: userService, AuthService: authService, AccountService: accountService}
+}
+
+func (h *UserHandler) GetMe(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	user, err := h.UserService.GetMe(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: user,
+	})
+}
+
+func (h *UserHandler) UpdateMe(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.UserUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	user, err := h.UserService.UpdateMe(ctx, claims.UserId, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrUpdatingUser,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    user,
+		Message: messages.MsgProfileUpdated,
+	})
+}
+
+func (h *UserHandler) GetUser(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidUserID,
+			Error:   err.Error(),
+		})
+	}
+
+	user, err := h.UserService.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: user,
+	})
+}
+
+func (h *UserHandler) ChangePassword(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	tokens, err := h.AuthService.ChangePassword(ctx, claims.UserId, claims.SessionId, &req)
+	if err != nil {
+		if errors.Is(err, messages.ErrWrongPassword) {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: messages.ErrWrongPassword.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    tokens,
+		Message: messages.MsgPasswordChanged,
+	})
+}
+
+func (h *UserHandler) EnrollMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	enrollment, err := h.AuthService.EnrollMfa(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrMfaAlreadyEnabled) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrMfaAlreadyEnabled.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: enrollment,
+	})
+}
+
+func (h *UserHandler) ConfirmMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.MfaCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	codes, err := h.AuthService.ConfirmMfa(ctx, claims.UserId, req.Code)
+	if err != nil {
+		if errors.Is(err, messages.ErrMfaAlreadyEnabled) || errors.Is(err, messages.ErrMfaNotEnrolled) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrInvalidMfaCode) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidMfaCode.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrTooManyAttempts) {
+			return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: messages.ErrTooManyAttempts.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data:    codes,
+		Message: messages.MsgMfaEnabled,
+	})
+}
+
+func (h *UserHandler) DisableMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.MfaCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.AuthService.DisableMfa(ctx, claims.UserId, req.Code)
+	if err != nil {
+		if errors.Is(err, messages.ErrMfaNotEnabled) || errors.Is(err, messages.ErrMfaRequired) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrInvalidMfaCode) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: messages.ErrInvalidMfaCode.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		if errors.Is(err, messages.ErrTooManyAttempts) {
+			return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: messages.ErrTooManyAttempts.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgMfaDisabled,
+	})
+}
+
+func (h *UserHandler) GetPersonalTokens(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	tokens, err := h.AuthService.GetPersonalTokens(ctx, claims.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: tokens,
+	})
+}
+
+func (h *UserHandler) CreatePersonalToken(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	var req models.PersonalTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrBadRequest,
+			Error:   err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrValidationFailed,
+			Error:   err.Error(),
+		})
+	}
+
+	token, err := h.AuthService.CreatePersonalToken(ctx, claims.UserId, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response.SuccessResponse{
+		Data:    token,
+		Message: messages.MsgTokenCreated,
+	})
+}
+
+func (h *UserHandler) RevokePersonalToken(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	tokenId, err := strconv.Atoi(c.Param("tokenId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidTokenID,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.AuthService.RevokePersonalToken(ctx, claims.UserId, tokenId)
+	if err != nil {
+		if errors.Is(err, messages.ErrTokenNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrTokenNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgTokenRevoked,
+	})
+}
+
+func (h *UserHandler) GetSessions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	sessions, err := h.AuthService.GetSessions(ctx, claims.UserId, claims.SessionId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Data: sessions,
+	})
+}
+
+func (h *UserHandler) RevokeSession(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.AuthService.RevokeSession(ctx, claims.UserId, c.Param("sessionId"))
+	if err != nil {
+		if errors.Is(err, messages.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrSessionNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgSessionRevoked,
+	})
+}
+
+func (h *UserHandler) Export(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	export, err := h.AccountService.Export(ctx, claims.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrCreatingExport,
+			Error:   err.Error(),
+		})
+	}
+
+	if export.Status == models.ExportStatusReady {
+		return c.Attachment(*export.FilePath, "article-hub-export.zip")
+	}
+
+	return c.JSON(http.StatusAccepted, response.SuccessResponse{
+		Data:    export,
+		Message: messages.MsgExportPending,
+	})
+}
+
+func (h *UserHandler) RequestExport(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	export, err := h.AccountService.RequestExport(ctx, claims.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrCreatingExport,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, response.SuccessResponse{
+		Data:    export,
+		Message: messages.MsgExportPending,
+	})
+}
+
+func (h *UserHandler) DeleteMe(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	deletion, err := h.AccountService.RequestDeletion(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: messages.ErrUserNotFound.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrDeletingAccount,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, response.SuccessResponse{
+		Data:    deletion,
+		Message: messages.MsgDeletionScheduled,
+	})
+}
+
+func (h *UserHandler) RestoreMe(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	claims, err := currentClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: messages.ErrInvalidToken,
+			Error:   err.Error(),
+		})
+	}
+
+	err = h.AccountService.CancelDeletion(ctx, claims.UserId)
+	if err != nil {
+		if errors.Is(err, messages.ErrDeletionNotScheduled) {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Code:    http.StatusConflict,
+				Message: messages.ErrDeletionNotScheduled.Error(),
+				Error:   err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: messages.ErrInternalServer,
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessResponse{
+		Message: messages.MsgDeletionCanceled,
+	})
+}
@@ -0,0 +1,203 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"restapp/config"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+type Key struct {
+	Kid        string
+	Method     jwt.SigningMethod
+	ActiveFrom time.Time
+	signKey    interface{}
+	verifyKey  interface{}
+	public     interface{}
+}
+
+func (k *Key) SignKey() interface{} {
+	return k.signKey
+}
+
+func (k *Key) VerifyKey() interface{} {
+	return k.verifyKey
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type Keyring struct {
+	keys    []*Key
+	overlap time.Duration
+}
+
+func New(cfg *config.Config) (*Keyring, error) {
+	if len(cfg.JWT.Keys) == 0 {
+		return NewHMAC(cfg.JWT.Secret), nil
+	}
+
+	keys := make([]*Key, 0, len(cfg.JWT.Keys))
+	seen := make(map[string]bool)
+	for _, keyCfg := range cfg.JWT.Keys {
+		if keyCfg.Kid == "" || seen[keyCfg.Kid] {
+			return nil, fmt.Errorf("jwt key %q: kid must be unique and non-empty", keyCfg.Kid)
+		}
+		seen[keyCfg.Kid] = true
+
+		key, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %v", keyCfg.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ActiveFrom.Before(keys[j].ActiveFrom)
+	})
+
+	return &Keyring{keys: keys, overlap: time.Duration(cfg.JWT.KeyOverlap) * time.Second}, nil
+}
+
+func NewHMAC(secret string) *Keyring {
+	key := &Key{Method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+	return &Keyring{keys: []*Key{key}}
+}
+
+func (r *Keyring) Signing(now time.Time) (*Key, error) {
+	var active *Key
+	for _, key := range r.keys {
+		if key.ActiveFrom.After(now) {
+			break
+		}
+		active = key
+	}
+	if active == nil {
+		return nil, ErrNoSigningKey
+	}
+	return active, nil
+}
+
+func (r *Keyring) Verification(kid string, now time.Time) (*Key, error) {
+	for i, key := range r.keys {
+		if key.Kid != kid {
+			continue
+		}
+		if key.ActiveFrom.After(now) || r.retired(i, now) {
+			return nil, ErrUnknownKey
+		}
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (r *Keyring) JWKS(now time.Time) *JWKS {
+	set := &JWKS{Keys: []JWK{}}
+	for i, key := range r.keys {
+		if key.public == nil || r.retired(i, now) {
+			continue
+		}
+		set.Keys = append(set.Keys, publicJWK(key))
+	}
+	return set
+}
+
+func (r *Keyring) retired(i int, now time.Time) bool {
+	for _, next := range r.keys[i+1:] {
+		if !next.ActiveFrom.After(now) {
+			return now.After(next.ActiveFrom.Add(r.overlap))
+		}
+	}
+	return false
+}
+
+func loadKey(cfg config.JWTKey) (*Key, error) {
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{Kid: cfg.Kid, signKey: private}
+	if cfg.ActiveFrom != "" {
+		key.ActiveFrom, err = time.Parse(time.RFC3339, cfg.ActiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active_from: %v", err)
+		}
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.public = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.public = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+	key.verifyKey = key.public
+
+	return key, nil
+}
+
+func publicJWK(key *Key) JWK {
+	jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+
+	switch k := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	}
+
+	return jwk
+}
@@ -9,6 +9,7 @@ var (
 	ErrInvalidCredentials    = errors.New("invalid email or password")
 	ErrEmailAlreadyExists    = errors.New("email already exists")
 	ErrUsernameAlreadyExists = errors.New("username already exists")
+	ErrUsernameReserved      = errors.New("username is reserved")
 	ErrInvalidToken          = errors.New("invalid token")
 	ErrMissingToken          = errors.New("missing authorization token")
 	ErrParsingToken          = errors.New("error parsing token")
@@ -21,6 +22,36 @@ var (
 	ErrComparingPasswords    = errors.New("error comparing passwords")
 	ErrCreatingUser          = errors.New("error creating user")
 	ErrGeneratingToken       = errors.New("error generating token")
+	ErrInvalidRefreshToken   = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired   = errors.New("refresh token expired")
+	ErrRefreshTokenReused    = errors.New("refresh token reuse detected")
+	ErrStoringRefreshToken   = errors.New("error storing refresh token")
+	ErrTokenRevoked          = errors.New("token has been revoked")
+	ErrRevokingToken         = errors.New("error revoking token")
+	ErrInvalidResetToken     = errors.New("invalid or expired reset token")
+	ErrStoringResetToken     = errors.New("error storing reset token")
+	ErrSendingMail           = errors.New("error sending mail")
+	ErrWrongPassword         = errors.New("current password is incorrect")
+	ErrInvalidVerification   = errors.New("invalid or expired verification link")
+	ErrAlreadyVerified       = errors.New("email is already verified")
+	ErrVerificationThrottled = errors.New("verification email was sent recently, try again later")
+	ErrEmailNotVerified      = errors.New("email is not verified")
+	ErrTooManyAttempts       = errors.New("too many login attempts, try again later")
+	ErrMfaAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrMfaNotEnabled         = errors.New("two-factor authentication is not enabled")
+	ErrMfaNotEnrolled        = errors.New("two-factor enrollment has not been started")
+	ErrInvalidMfaCode        = errors.New("invalid two-factor code")
+	ErrMfaRequired           = errors.New("two-factor authentication is required for this role")
+	ErrUnknownProvider       = errors.New("unknown identity provider")
+	ErrInvalidOIDCState      = errors.New("invalid or expired login state")
+	ErrOIDCExchange          = errors.New("error signing in with identity provider")
+	ErrOIDCEmailMissing      = errors.New("identity provider did not return an email address")
+	ErrOIDCEmailUnverified   = errors.New("identity provider email is not verified")
+	ErrOIDCAccountUnverified = errors.New("an account with this email exists but has not verified it; sign in with your password first")
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrSessionRevoked        = errors.New("session has been revoked")
+	ErrTokenNotFound         = errors.New("token not found")
+	ErrInvalidTokenID        = errors.New("invalid token ID")
 
 	// Article messages
 	ErrFetchArticles      = errors.New("failed to fetch articles")
@@ -28,12 +59,14 @@ var (
 	ErrInvalidArticleData = errors.New("invalid article data")
 	ErrInvalidArticleID   = errors.New("invalid article ID")
 	ErrGettingArticles    = errors.New("error getting articles")
-	ErrLikeExists         = errors.New("like already exists")
+	ErrInvalidReaction    = errors.New("invalid reaction type")
 	MsgArticleCreated     = "article successfully created"
 	MsgArticleUpdated     = "article successfully updated"
 	MsgArticleDeleted     = "article successfully deleted"
 	MsgArticleLiked       = "article successfully liked"
 	MsgArticleUnliked     = "article successfully unliked"
+	MsgReactionAdded      = "reaction successfully added"
+	MsgReactionRemoved    = "reaction successfully removed"
 
 	// Validation messages
 	ErrValidationFailed   = errors.New("validation failed")
@@ -45,6 +78,20 @@ var (
 	// Success messages
 	MsgRegistrationSuccess = "registration successful"
 	MsgLoginSuccess        = "login successful"
+	MsgTokenRefreshed      = "token successfully refreshed"
+	MsgLoggedOut           = "successfully logged out"
+	MsgLoggedOutEverywhere = "successfully logged out from all sessions"
+	MsgPasswordChanged     = "password successfully changed"
+	MsgPasswordResetSent   = "if the email is registered, a reset link has been sent"
+	MsgPasswordReset       = "password successfully reset"
+	MsgEmailVerified       = "email successfully verified"
+	MsgVerificationSent    = "verification email sent"
+	MsgMfaEnabled          = "two-factor authentication enabled"
+	MsgMfaDisabled         = "two-factor authentication disabled"
+	MsgMfaRequired         = "two-factor code required"
+	MsgSessionRevoked      = "session successfully revoked"
+	MsgTokenCreated        = "token successfully created"
+	MsgTokenRevoked        = "token successfully revoked"
 
 	// Server errors
 	ErrInternalServer     = errors.New("internal server error")
@@ -52,11 +99,65 @@ var (
 	ErrDatabaseOperation  = errors.New("database operation failed")
 
 	// Comment messages
-	MsgCommentCreated  = "comment successfully created"
-	MsgCommentUpdated  = "comment successfully updated"
-	ErrGettingComments = errors.New("error to getting comments")
-	ErrCreatingComment = errors.New("error creating comment")
+	MsgCommentCreated   = "comment successfully created"
+	MsgCommentUpdated   = "comment successfully updated"
+	MsgCommentDeleted   = "comment successfully deleted"
+	MsgCommentVoted     = "vote successfully recorded"
+	MsgCommentUnvoted   = "vote successfully removed"
+	MsgCommentPending   = "comment is awaiting approval"
+	MsgCommentApproved  = "comment successfully approved"
+	MsgCommentRejected  = "comment successfully rejected"
+	MsgSettingsUpdated  = "comment settings successfully updated"
+	ErrGettingComments  = errors.New("error to getting comments")
+	ErrCreatingComment  = errors.New("error creating comment")
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrInvalidCommentID = errors.New("invalid comment ID")
+	ErrParentMismatch   = errors.New("parent comment belongs to another article")
+	ErrCommentTooDeep   = errors.New("maximum reply depth exceeded")
+	ErrUpdatingComment  = errors.New("error updating comment")
+	ErrDeletingComment  = errors.New("error deleting comment")
+	ErrInvalidSort      = errors.New("invalid sort order")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrVotingComment    = errors.New("error voting on comment")
+	ErrCommentsLocked   = errors.New("comments are locked")
+	ErrFollowersOnly    = errors.New("comments are restricted to followers")
+	ErrVerifiedOnly     = errors.New("comments are restricted to verified users")
+	ErrCommentSettings  = errors.New("error saving comment settings")
+
+	// Mention messages
+	ErrSavingMentions  = errors.New("error saving mentions")
+	ErrGettingMentions = errors.New("error getting mentions")
+
+	// Notification messages
+	ErrGettingNotifications  = errors.New("error getting notifications")
+	ErrNotificationNotFound  = errors.New("notification not found")
+	ErrInvalidNotificationID = errors.New("invalid notification ID")
+	MsgNotificationRead      = "notification marked as read"
 
 	// User messages
-	ErrGettingUser = errors.New("error getting user")
+	ErrGettingUser    = errors.New("error getting user")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrForbidden      = errors.New("forbidden")
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrUpdatingUser   = errors.New("error updating user")
+	ErrInvalidUserID  = errors.New("invalid user ID")
+	MsgRoleUpdated    = "role successfully updated"
+	MsgUserUnlocked   = "user successfully unlocked"
+	MsgProfileUpdated = "profile successfully updated"
+
+	// Follow messages
+	ErrFollowing        = errors.New("error updating follow")
+	ErrCannotFollowSelf = errors.New("users cannot follow themselves")
+	ErrGettingFeed      = errors.New("error getting feed")
+	MsgFollowed         = "user successfully followed"
+	MsgUnfollowed       = "user successfully unfollowed"
+
+	// Account messages
+	ErrCreatingExport       = errors.New("error creating data export")
+	ErrExportNotFound       = errors.New("data export not found")
+	ErrDeletingAccount      = errors.New("error deleting account")
+	ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+	MsgExportPending        = "data export is being prepared"
+	MsgDeletionScheduled    = "account scheduled for deletion"
+	MsgDeletionCanceled     = "account deletion canceled"
 )
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"net/http"
+	"restapp/internal/models"
 	"restapp/internal/services"
 	"strings"
 
@@ -33,7 +34,50 @@ func (h *AuthMiddleware) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
 		}
 
+		// Last-seen is best effort; a failed write must not reject the request.
+		_ = h.s.TouchSession(c.Request().Context(), claims)
+
+		c.Set("claims", claims)
 		c.Set("user_id", claims.UserId)
+		c.Set("role", claims.Role)
+		c.Set("scopes", claims.Scopes)
+
+		return next(c)
+	}
+}
+
+func (h *AuthMiddleware) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, _ := c.Get("role").(string)
+			if !models.HasPermission(role, permission) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Недостаточно прав"})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (h *AuthMiddleware) RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := models.Claims{}
+			claims.Scopes, _ = c.Get("scopes").([]string)
+			if !claims.HasScope(scope) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Недостаточно прав токена"})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (h *AuthMiddleware) RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if scopes, _ := c.Get("scopes").([]string); scopes != nil {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Персональный токен здесь не допускается"})
+		}
 
 		return next(c)
 	}
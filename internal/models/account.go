@@ -0,0 +1,53 @@
+package models
+
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+
+	ArticlePolicyDelete   = "delete"
+	ArticlePolicyReassign = "reassign"
+
+	DeletedUsernamePrefix = "deleted-"
+)
+
+type DataExport struct {
+	Id          int     `json:"id" db:"id"`
+	UserId      int     `json:"-" db:"user_id"`
+	Status      string  `json:"status" db:"status"`
+	FilePath    *string `json:"-" db:"file_path"`
+	CompletedAt *string `json:"completed_at" db:"completed_at"`
+	ExpiresAt   *string `json:"expires_at" db:"expires_at"`
+	CreatedAt   string  `json:"requested_at" db:"created_at"`
+}
+
+type ExportComment struct {
+	Id           int     `json:"id" db:"id"`
+	ArticleId    int     `json:"article_id" db:"article_id"`
+	ArticleTitle string  `json:"article_title" db:"article_title"`
+	ParentId     *int    `json:"parent_id" db:"parent_id"`
+	Content      string  `json:"content" db:"content"`
+	Status       string  `json:"status" db:"status"`
+	EditedAt     *string `json:"edited_at" db:"edited_at"`
+	CreatedAt    string  `json:"created_at" db:"created_at"`
+}
+
+type ExportReaction struct {
+	ArticleId    int    `json:"article_id" db:"article_id"`
+	ArticleTitle string `json:"article_title" db:"article_title"`
+	Type         string `json:"type" db:"type"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+}
+
+type ExportData struct {
+	ExportedAt string           `json:"exported_at"`
+	Profile    *CurrentUser     `json:"profile"`
+	Articles   []Article        `json:"articles"`
+	Comments   []ExportComment  `json:"comments"`
+	Reactions  []ExportReaction `json:"reactions"`
+}
+
+type AccountDeletion struct {
+	ScheduledFor string `json:"scheduled_for" db:"deletion_scheduled_at"`
+}
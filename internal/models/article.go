@@ -8,14 +8,17 @@ import (
 )
 
 type Article struct {
-	Id        int       `json:"id" db:"id"`
-	UserId    int       `json:"user_id" db:"userId"`
-	Title     string    `json:"title" db:"title"`
-	Content   string    `json:"content" db:"content"`
-	Likes     int       `json:"likes" db:"likes"`
-	Comments  []Comment `json:"comments"`
-	CreatedAt string    `json:"created_at" db:"created_at"`
-	UpdatedAt string    `json:"updated_at" db:"updated_at"`
+	Id            int            `json:"id" db:"id"`
+	UserId        int            `json:"user_id" db:"user_id"`
+	Title         string         `json:"title" db:"title"`
+	Content       string         `json:"content" db:"content"`
+	LikesCount    int            `json:"likes_count" db:"likes_count"`
+	CommentsCount int            `json:"comments_count" db:"comments_count"`
+	Reactions     map[string]int `json:"reactions"`
+	Mentions      []Mention      `json:"mentions"`
+	Comments      []Comment      `json:"comments,omitempty"`
+	CreatedAt     string         `json:"created_at" db:"created_at"`
+	UpdatedAt     string         `json:"updated_at" db:"updated_at"`
 }
 
 type ArticleRequest struct {
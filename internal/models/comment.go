@@ -3,20 +3,100 @@ package models
 import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
+	"math"
 	"strings"
 )
 
+const DeletedCommentContent = "[deleted]"
+
+const (
+	CommentStatusApproved = "approved"
+	CommentStatusPending  = "pending"
+	CommentStatusRejected = "rejected"
+)
+
+const (
+	CommentAudienceEveryone  = "everyone"
+	CommentAudienceFollowers = "followers"
+	CommentAudienceVerified  = "verified"
+)
+
+const (
+	CommentSortOldest = "oldest"
+	CommentSortNewest = "newest"
+	CommentSortTop    = "top"
+)
+
 type Comment struct {
+	Id           int       `json:"id" db:"id"`
+	ArticleId    int       `json:"article_id" db:"article_id"`
+	UserId       int       `json:"user_id" db:"user_id"`
+	ParentId     *int      `json:"parent_id" db:"parent_id"`
+	Depth        int       `json:"depth" db:"depth"`
+	Path         string    `json:"path" db:"path"`
+	Content      string    `json:"content" db:"content"`
+	EditedAt     *string   `json:"edited_at" db:"edited_at"`
+	DeletedAt    *string   `json:"deleted_at,omitempty" db:"deleted_at"`
+	Status       string    `json:"status" db:"status"`
+	Upvotes      int       `json:"upvotes" db:"upvotes"`
+	Downvotes    int       `json:"downvotes" db:"downvotes"`
+	Score        float64   `json:"score" db:"score"`
+	MyVote       int       `json:"my_vote" db:"-"`
+	Mentions     []Mention `json:"mentions"`
+	RepliesCount int       `json:"replies_count" db:"replies_count"`
+	Replies      []Comment `json:"replies,omitempty"`
+	CreatedAt    string    `json:"created_at" db:"created_at"`
+	UpdatedAt    string    `json:"updated_at" db:"updated_at"`
+}
+
+type CommentEdit struct {
 	Id        int    `json:"id" db:"id"`
-	ArticleId int    `json:"article_id" db:"article_id"`
-	UserId    int    `json:"user_id" db:"user_id"`
+	CommentId int    `json:"comment_id" db:"comment_id"`
+	EditorId  int    `json:"editor_id" db:"editor_id"`
 	Content   string `json:"content" db:"content"`
 	CreatedAt string `json:"created_at" db:"created_at"`
-	UpdatedAt string `json:"updated_at" db:"updated_at"`
+}
+
+type CommentSettings struct {
+	ArticleId       int    `json:"article_id" db:"article_id"`
+	AuthorId        int    `json:"-" db:"author_id"`
+	Locked          bool   `json:"locked" db:"locked"`
+	Audience        string `json:"audience" db:"audience"`
+	RequireApproval bool   `json:"require_approval" db:"require_approval"`
+}
+
+type CommentSettingsRequest struct {
+	Locked          bool   `json:"locked"`
+	Audience        string `json:"audience" validate:"required,oneof=everyone followers verified"`
+	RequireApproval bool   `json:"require_approval"`
+}
+
+type CommentVoteRequest struct {
+	Value int `json:"value" validate:"required,oneof=-1 1"`
+}
+
+type CommentCursor struct {
+	Score float64 `json:"s"`
+	Id    int     `json:"i"`
+}
+
+type CommentQuery struct {
+	Sort       string
+	Cursor     string
+	Limit      int
+	MaxReplies int
+	Flat       bool
+	ViewerId   int
+}
+
+type CommentList struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 type CommentRequest struct {
-	Content string `json:"content" validate:"required,min=10,max=1000" msg:"Content must be between 10 and 1000 characters"`
+	ParentId *int   `json:"parent_id"`
+	Content  string `json:"content" validate:"required,min=10,max=1000" msg:"Content must be between 10 and 1000 characters"`
 }
 
 func (c *CommentRequest) Validate() error {
@@ -32,3 +112,42 @@ func (c *CommentRequest) Validate() error {
 	}
 	return nil
 }
+
+func (v *CommentVoteRequest) Validate() error {
+	validate := validator.New()
+
+	err := validate.Struct(v)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field %s %s\n", err.Field(), err.Tag()))
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
+
+func WilsonScore(upvotes, downvotes int) float64 {
+	n := float64(upvotes + downvotes)
+	if n == 0 {
+		return 0
+	}
+
+	const z = 1.96
+	phat := float64(upvotes) / n
+	return (phat + z*z/(2*n) - z*math.Sqrt((phat*(1-phat)+z*z/(4*n))/n)) / (1 + z*z/n)
+}
+
+func (r *CommentSettingsRequest) Validate() error {
+	validate := validator.New()
+
+	err := validate.Struct(r)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field %s %s\n", err.Field(), err.Tag()))
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
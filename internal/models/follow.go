@@ -0,0 +1,28 @@
+package models
+
+type FollowUser struct {
+	Id          int     `json:"id" db:"id"`
+	Username    string  `json:"username" db:"username"`
+	DisplayName *string `json:"display_name" db:"display_name"`
+	AvatarUrl   *string `json:"avatar_url" db:"avatar_url"`
+	FollowedAt  string  `json:"followed_at" db:"followed_at"`
+}
+
+type FollowCursor struct {
+	FollowedAt string `json:"t"`
+	Id         int    `json:"i"`
+}
+
+type FollowList struct {
+	Users      []FollowUser `json:"users"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type FeedCursor struct {
+	Id int `json:"i"`
+}
+
+type Feed struct {
+	Articles   []Article `json:"articles"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
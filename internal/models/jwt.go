@@ -1,8 +1,77 @@
 package models
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+)
 
 type Claims struct {
-	UserId int `json:"user_id"`
+	UserId       int      `json:"user_id"`
+	Role         string   `json:"role"`
+	TokenVersion int      `json:"ver"`
+	Scopes       []string `json:"scopes,omitempty"`
+	SessionId    string   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
+
+func (c *Claims) IsPersonalToken() bool {
+	return c.Scopes != nil
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	if !c.IsPersonalToken() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type RefreshToken struct {
+	Id        int     `db:"id"`
+	UserId    int     `db:"user_id"`
+	FamilyId  string  `db:"family_id"`
+	TokenHash string  `db:"token_hash"`
+	Expired   bool    `db:"expired"`
+	RotatedAt *string `db:"rotated_at"`
+	RevokedAt *string `db:"revoked_at"`
+}
+
+type RevokedToken struct {
+	Jti       string `db:"jti"`
+	ExpiresAt int64  `db:"expires_at"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (r *RefreshRequest) Validate() error {
+	validate := validator.New()
+
+	err := validate.Struct(r)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field %s %s\n", err.Field(), err.Tag()))
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
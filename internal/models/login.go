@@ -0,0 +1,13 @@
+package models
+
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+	LoginScopeMfa     = "mfa"
+)
+
+type LoginThrottle struct {
+	Failures     int  `db:"failures"`
+	LockedFor    int  `db:"locked_for"`
+	SinceFailure *int `db:"since_failure"`
+}
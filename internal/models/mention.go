@@ -0,0 +1,15 @@
+package models
+
+const (
+	MentionSourceArticle = "article"
+	MentionSourceComment = "comment"
+)
+
+type Mention struct {
+	SourceType string `json:"-" db:"source_type"`
+	SourceId   int    `json:"-" db:"source_id"`
+	UserId     int    `json:"user_id" db:"user_id"`
+	Username   string `json:"username" db:"username"`
+	Offset     int    `json:"offset" db:"position"`
+	Length     int    `json:"length" db:"length"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+const AudienceMfa = "mfa"
+
+type MfaEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MfaChallenge struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MfaCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MfaVerifyRequest struct {
+	MfaToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+func (m *MfaCodeRequest) Validate() error {
+	return validateMfaRequest(m)
+}
+
+func (m *MfaVerifyRequest) Validate() error {
+	return validateMfaRequest(m)
+}
+
+func validateMfaRequest(req interface{}) error {
+	validate := validator.New()
+
+	err := validate.Struct(req)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field %s %s\n", err.Field(), err.Tag()))
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package models
+
+const NotificationMention = "mention"
+
+type Notification struct {
+	Id         int     `json:"id" db:"id"`
+	UserId     int     `json:"user_id" db:"user_id"`
+	ActorId    int     `json:"actor_id" db:"actor_id"`
+	Type       string  `json:"type" db:"type"`
+	SourceType string  `json:"source_type" db:"source_type"`
+	SourceId   int     `json:"source_id" db:"source_id"`
+	ReadAt     *string `json:"read_at" db:"read_at"`
+	CreatedAt  string  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+type OIDCState struct {
+	State        string `db:"state"`
+	Provider     string `db:"provider"`
+	CodeVerifier string `db:"code_verifier"`
+	Nonce        string `db:"nonce"`
+}
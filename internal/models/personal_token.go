@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+const (
+	PersonalTokenPrefix = "pat_"
+
+	ScopeArticlesRead  = "articles:read"
+	ScopeArticlesWrite = "articles:write"
+	ScopeCommentsWrite = "comments:write"
+)
+
+var Scopes = []string{ScopeArticlesRead, ScopeArticlesWrite, ScopeCommentsWrite}
+
+type PersonalToken struct {
+	Id          int      `json:"id" db:"id"`
+	UserId      int      `json:"-" db:"user_id"`
+	Role        string   `json:"-" db:"role"`
+	Name        string   `json:"name" db:"name"`
+	TokenPrefix string   `json:"prefix" db:"token_prefix"`
+	ScopeList   string   `json:"-" db:"scopes"`
+	Scopes      []string `json:"scopes"`
+	Version     int      `json:"-" db:"token_version"`
+	ExpiresAt   *string  `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *string  `json:"last_used_at" db:"last_used_at"`
+	CreatedAt   string   `json:"created_at" db:"created_at"`
+}
+
+type CreatedPersonalToken struct {
+	PersonalToken
+	Token string `json:"token"`
+}
+
+type PersonalTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=articles:read articles:write comments:write"`
+	ExpiresInDays *int     `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+func (p *PersonalTokenRequest) Validate() error {
+	validate := validator.New()
+
+	err := validate.Struct(p)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			switch err.Tag() {
+			case "oneof":
+				sb.WriteString(fmt.Sprintf("Field %s must be one of %s\n", err.Field(), strings.Join(Scopes, ", ")))
+			default:
+				sb.WriteString(fmt.Sprintf("Field %s %s\n", err.Field(), err.Tag()))
+			}
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package models
+
+const ReactionLike = "like"
+
+type Reaction struct {
+	Id        int    `json:"id" db:"id"`
+	ArticleId int    `json:"article_id" db:"article_id"`
+	UserId    int    `json:"user_id" db:"user_id"`
+	Type      string `json:"type" db:"type"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	UpdatedAt string `json:"updated_at" db:"updated_at"`
+}
+
+type ReactionCount struct {
+	ArticleId int    `db:"article_id"`
+	Type      string `db:"type"`
+	Count     int    `db:"count"`
+}
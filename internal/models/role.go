@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+const (
+	RoleUser      = "user"
+	RoleAuthor    = "author"
+	RoleEditor    = "editor"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+const (
+	PermArticleRead      = "article:read"
+	PermArticleReact     = "article:react"
+	PermArticleCreate    = "article:create"
+	PermArticleUpdateOwn = "article:update:own"
+	PermArticleUpdateAny = "article:update:any"
+	PermArticleDeleteOwn = "article:delete:own"
+	PermArticleDeleteAny = "article:delete:any"
+	PermCommentCreate    = "comment:create"
+	PermCommentModerate  = "comment:moderate"
+	PermUserRoleUpdate   = "user:role:update"
+	PermUserUnlock       = "user:unlock"
+)
+
+// DefaultRole is assigned on sign-up; RoleUser is kept for accounts that
+// must not publish.
+const DefaultRole = RoleAuthor
+
+var Roles = []string{RoleUser, RoleAuthor, RoleEditor, RoleModerator, RoleAdmin}
+
+var rolePermissions = map[string][]string{
+	RoleUser:      {PermArticleRead, PermArticleReact, PermCommentCreate},
+	RoleAuthor:    {PermArticleCreate, PermArticleUpdateOwn, PermArticleDeleteOwn},
+	RoleEditor:    {PermArticleUpdateAny},
+	RoleModerator: {PermArticleDeleteAny, PermCommentModerate},
+	RoleAdmin:     {PermUserRoleUpdate, PermUserUnlock},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func RolePermissions(role string) []string {
+	var permissions []string
+	for _, r := range Roles {
+		permissions = append(permissions, rolePermissions[r]...)
+		if r == role {
+			return permissions
+		}
+	}
+	return nil
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range RolePermissions(role) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresMfa reports whether a role grants moderation powers, which are only
+// given to accounts with two-factor authentication enabled.
+func RequiresMfa(role string) bool {
+	return HasPermission(role, PermCommentModerate)
+}
+
+type RoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+func (r *RoleRequest) Validate() error {
+	validate := validator.New()
+
+	err := validate.Struct(r)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			sb.WriteString(fmt.Sprintf("Field %s %s\n", err.Field(), err.Tag()))
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+
+	if !IsValidRole(r.Role) {
+		return fmt.Errorf("Field Role must be one of %s", strings.Join(Roles, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package models
+
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type Session struct {
+	Id         string `json:"id" db:"id"`
+	UserId     int    `json:"-" db:"user_id"`
+	UserAgent  string `json:"user_agent" db:"user_agent"`
+	IP         string `json:"ip" db:"ip"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+	LastSeenAt string `json:"last_seen_at" db:"last_seen_at"`
+	Current    bool   `json:"current"`
+}
@@ -7,13 +7,83 @@ import (
 )
 
 type User struct {
-	Id        int    `json:"id" db:"id"`
-	Username  string `json:"username" db:"username"`
-	Password  string `json:"password" db:"password"`
-	Email     string `json:"email" db:"email"`
-	Role      string `json:"role" db:"role"`
-	CreatedAt string `json:"created_at" db:"created_at"`
-	UpdatedAt string `json:"updated_at" db:"updated_at"`
+	Id              int     `json:"id" db:"id"`
+	Username        string  `json:"username" db:"username"`
+	Password        string  `json:"-" db:"password"`
+	Email           string  `json:"email" db:"email"`
+	Role            string  `json:"role" db:"role"`
+	EmailVerifiedAt *string `json:"email_verified_at" db:"email_verified_at"`
+	DisplayName     *string `json:"display_name" db:"display_name"`
+	Bio             *string `json:"bio" db:"bio"`
+	AvatarUrl       *string `json:"avatar_url" db:"avatar_url"`
+	MfaSecret       *string `json:"-" db:"mfa_secret"`
+	MfaEnabledAt    *string `json:"mfa_enabled_at" db:"mfa_enabled_at"`
+	CreatedAt       string  `json:"created_at" db:"created_at"`
+	UpdatedAt       string  `json:"updated_at" db:"updated_at"`
+}
+
+type PublicUser struct {
+	Id             int     `json:"id" db:"id"`
+	Username       string  `json:"username" db:"username"`
+	DisplayName    *string `json:"display_name" db:"display_name"`
+	Bio            *string `json:"bio" db:"bio"`
+	AvatarUrl      *string `json:"avatar_url" db:"avatar_url"`
+	Role           string  `json:"role" db:"role"`
+	ArticlesCount  int     `json:"articles_count" db:"articles_count"`
+	FollowersCount int     `json:"followers_count" db:"followers_count"`
+	FollowingCount int     `json:"following_count" db:"following_count"`
+	JoinedAt       string  `json:"joined_at" db:"created_at"`
+}
+
+type CurrentUser struct {
+	PublicUser
+	Email           string  `json:"email"`
+	EmailVerifiedAt *string `json:"email_verified_at"`
+	MfaEnabled      bool    `json:"mfa_enabled"`
+}
+
+type UserUpdateRequest struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	Bio         *string `json:"bio" validate:"omitempty,max=1000"`
+	AvatarUrl   *string `json:"avatar_url" validate:"omitempty,url,max=512"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+func NewPublicUser(user *User) *PublicUser {
+	return &PublicUser{
+		Id:          user.Id,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarUrl:   user.AvatarUrl,
+		Role:        user.Role,
+		JoinedAt:    user.CreatedAt,
+	}
+}
+
+func NewCurrentUser(user *User, articlesCount int) *CurrentUser {
+	public := NewPublicUser(user)
+	public.ArticlesCount = articlesCount
+
+	return &CurrentUser{
+		PublicUser:      *public,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		MfaEnabled:      user.MfaEnabledAt != nil,
+	}
 }
 
 type RegisterRequest struct {
@@ -28,8 +98,9 @@ type LoginRequest struct {
 }
 
 type UserResponse struct {
-	User  interface{}
-	Token string
+	User         interface{}
+	Token        string
+	RefreshToken string
 }
 
 func (r *RegisterRequest) Validate() error {
@@ -54,6 +125,23 @@ func (r *RegisterRequest) Validate() error {
 		}
 		return fmt.Errorf("%s", sb.String())
 	}
+	return checkReservedUsername(r.Username)
+}
+
+// ValidateUsername applies the username rules of RegisterRequest to a name
+// that did not come from a registration form.
+func ValidateUsername(username string) error {
+	err := validator.New().StructPartial(&RegisterRequest{Username: username}, "Username")
+	if err != nil {
+		return err
+	}
+	return checkReservedUsername(username)
+}
+
+func checkReservedUsername(username string) error {
+	if strings.HasPrefix(strings.ToLower(username), DeletedUsernamePrefix) {
+		return fmt.Errorf("Field Username must not start with %s\n", DeletedUsernamePrefix)
+	}
 	return nil
 }
 
@@ -77,3 +165,59 @@ func (l *LoginRequest) Validate() error {
 	}
 	return nil
 }
+
+func (u *UserUpdateRequest) Validate() error {
+	validate := validator.New()
+
+	err := validate.Struct(u)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			switch err.Tag() {
+			case "max":
+				sb.WriteString(fmt.Sprintf("Field %s must not exceed %s characters\n", err.Field(), err.Param()))
+			case "url":
+				sb.WriteString(fmt.Sprintf("Field %s must be a valid URL\n", err.Field()))
+			default:
+				sb.WriteString(fmt.Sprintf("Field %s failed validation: %s\n", err.Field(), err.Tag()))
+			}
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
+
+func (c *ChangePasswordRequest) Validate() error {
+	return validatePasswordRequest(c)
+}
+
+func (f *ForgotPasswordRequest) Validate() error {
+	return validatePasswordRequest(f)
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	return validatePasswordRequest(r)
+}
+
+func validatePasswordRequest(req interface{}) error {
+	validate := validator.New()
+
+	err := validate.Struct(req)
+	if err != nil {
+		var sb strings.Builder
+		for _, err := range err.(validator.ValidationErrors) {
+			switch err.Tag() {
+			case "required":
+				sb.WriteString(fmt.Sprintf("Field %s is required\n", err.Field()))
+			case "email":
+				sb.WriteString(fmt.Sprintf("Field %s must be a valid email address\n", err.Field()))
+			case "min":
+				sb.WriteString(fmt.Sprintf("Field %s must be at least %s characters long\n", err.Field(), err.Param()))
+			default:
+				sb.WriteString(fmt.Sprintf("Field %s failed validation: %s\n", err.Field(), err.Tag()))
+			}
+		}
+		return fmt.Errorf("%s", sb.String())
+	}
+	return nil
+}
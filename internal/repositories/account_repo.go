@@ -0,0 +1,494 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type AccountRepositoryInterface interface {
+	CreateExport(ctx context.Context, userId int) (*models.DataExport, error)
+	GetLatestExport(ctx context.Context, userId int) (*models.DataExport, error)
+	ClaimExport(ctx context.Context, staleAfter int) (*models.DataExport, error)
+	CompleteExport(ctx context.Context, id int, filePath string, expiresIn int) error
+	FailExport(ctx context.Context, id int, reason string) error
+	PruneExports(ctx context.Context) ([]string, error)
+	GetExportData(ctx context.Context, userId int) (*models.ExportData, error)
+	ScheduleDeletion(ctx context.Context, userId int, grace int) (*models.AccountDeletion, error)
+	CancelDeletion(ctx context.Context, userId int) error
+	GetDueDeletions(ctx context.Context) ([]int, error)
+	GetUserIdByUsername(ctx context.Context, username string) (int, error)
+	PurgeAccount(ctx context.Context, userId int, reassignTo int) ([]string, error)
+}
+
+type AccountRepository struct {
+	db *sqlx.DB
+}
+
+func NewAccountRepository(db *sqlx.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+const exportColumns = `id, user_id, status, file_path, completed_at, expires_at, created_at`
+
+func (r *AccountRepository) CreateExport(ctx context.Context, userId int) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO data_exports (user_id, status) VALUES (?, ?)`,
+		userId,
+		models.ExportStatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrCreatingExport, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	var export models.DataExport
+	err = r.db.GetContext(ctx, &export, `SELECT `+exportColumns+` FROM data_exports WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &export, nil
+}
+
+func (r *AccountRepository) GetLatestExport(ctx context.Context, userId int) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var export models.DataExport
+	err := r.db.GetContext(
+		ctx,
+		&export,
+		`SELECT `+exportColumns+` FROM data_exports
+		 WHERE user_id = ? AND status <> ? AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		 ORDER BY id DESC LIMIT 1`,
+		userId,
+		models.ExportStatusFailed,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &export, nil
+}
+
+func (r *AccountRepository) ClaimExport(ctx context.Context, staleAfter int) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	var export models.DataExport
+	err = tx.GetContext(
+		ctx,
+		&export,
+		`SELECT `+exportColumns+` FROM data_exports
+		 WHERE status = ? OR (status = ? AND started_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND))
+		 ORDER BY id LIMIT 1
+		 FOR UPDATE SKIP LOCKED`,
+		models.ExportStatusPending,
+		models.ExportStatusProcessing,
+		staleAfter,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE data_exports SET status = ?, started_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		models.ExportStatusProcessing,
+		export.Id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	export.Status = models.ExportStatusProcessing
+	return &export, nil
+}
+
+func (r *AccountRepository) CompleteExport(ctx context.Context, id int, filePath string, expiresIn int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE data_exports
+		 SET status = ?, file_path = ?, completed_at = CURRENT_TIMESTAMP,
+		     expires_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND)
+		 WHERE id = ?`,
+		models.ExportStatusReady,
+		filePath,
+		expiresIn,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
+
+func (r *AccountRepository) FailExport(ctx context.Context, id int, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE data_exports SET status = ?, error = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		models.ExportStatusFailed,
+		reason,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
+
+func (r *AccountRepository) PruneExports(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	var paths []string
+	err = tx.SelectContext(
+		ctx,
+		&paths,
+		`SELECT file_path FROM data_exports
+		 WHERE expires_at <= CURRENT_TIMESTAMP AND file_path IS NOT NULL
+		 FOR UPDATE`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM data_exports WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return paths, nil
+}
+
+func (r *AccountRepository) GetExportData(ctx context.Context, userId int) (*models.ExportData, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	data := models.ExportData{
+		Articles:  []models.Article{},
+		Comments:  []models.ExportComment{},
+		Reactions: []models.ExportReaction{},
+	}
+
+	err := r.db.SelectContext(
+		ctx,
+		&data.Articles,
+		`SELECT id, user_id, title, content, likes_count, comments_count, created_at, updated_at
+		 FROM articles WHERE user_id = ? ORDER BY id`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	err = r.db.SelectContext(
+		ctx,
+		&data.Comments,
+		`SELECT c.id, c.article_id, a.title AS article_title, c.parent_id, c.content, c.status, c.edited_at, c.created_at
+		 FROM comments c
+		 JOIN articles a ON a.id = c.article_id
+		 WHERE c.user_id = ? AND c.deleted_at IS NULL
+		 ORDER BY c.id`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	err = r.db.SelectContext(
+		ctx,
+		&data.Reactions,
+		`SELECT r.article_id, a.title AS article_title, r.type, r.created_at
+		 FROM reactions r
+		 JOIN articles a ON a.id = r.article_id
+		 WHERE r.user_id = ?
+		 ORDER BY r.id`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &data, nil
+}
+
+func (r *AccountRepository) ScheduleDeletion(ctx context.Context, userId int, grace int) (*models.AccountDeletion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET deletion_scheduled_at = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND)
+		 WHERE id = ? AND deleted_at IS NULL AND deletion_scheduled_at IS NULL`,
+		grace,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+	}
+
+	var deletion models.AccountDeletion
+	err = r.db.GetContext(
+		ctx,
+		&deletion,
+		`SELECT deletion_scheduled_at FROM users WHERE id = ? AND deletion_scheduled_at IS NOT NULL`,
+		userId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &deletion, nil
+}
+
+func (r *AccountRepository) CancelDeletion(ctx context.Context, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET deletion_scheduled_at = NULL WHERE id = ? AND deletion_scheduled_at IS NOT NULL`,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if rowsAffected == 0 {
+		return messages.ErrDeletionNotScheduled
+	}
+	return nil
+}
+
+func (r *AccountRepository) GetDueDeletions(ctx context.Context) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ids []int
+	err := r.db.SelectContext(
+		ctx,
+		&ids,
+		`SELECT id FROM users WHERE deletion_scheduled_at <= CURRENT_TIMESTAMP ORDER BY deletion_scheduled_at`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return ids, nil
+}
+
+func (r *AccountRepository) GetUserIdByUsername(ctx context.Context, username string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var id int
+	err := r.db.GetContext(ctx, &id, `SELECT id FROM users WHERE LOWER(username) = LOWER(?) AND deleted_at IS NULL`, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, messages.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return id, nil
+}
+
+func (r *AccountRepository) PurgeAccount(ctx context.Context, userId int, reassignTo int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.GetContext(
+		ctx,
+		&email,
+		`SELECT email FROM users
+		 WHERE id = ? AND deletion_scheduled_at <= CURRENT_TIMESTAMP
+		 FOR UPDATE`,
+		userId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrDeletionNotScheduled
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+	}
+
+	var paths []string
+	err = tx.SelectContext(ctx, &paths, `SELECT file_path FROM data_exports WHERE user_id = ? AND file_path IS NOT NULL`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+	}
+
+	var votedComments []int
+	err = tx.SelectContext(ctx, &votedComments, `SELECT comment_id FROM comment_votes WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+	}
+
+	statements := []statement{
+		{
+			`UPDATE articles a
+			 JOIN reactions r ON r.article_id = a.id AND r.user_id = ? AND r.type = ?
+			 SET a.likes_count = GREATEST(a.likes_count - 1, 0)`,
+			[]interface{}{userId, models.ReactionLike},
+		},
+		{`DELETE FROM reactions WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM comment_votes WHERE user_id = ?`, []interface{}{userId}},
+	}
+
+	if reassignTo > 0 {
+		statements = append(statements, statement{`UPDATE articles SET user_id = ? WHERE user_id = ?`, []interface{}{reassignTo, userId}})
+	} else {
+		statements = append(statements, deleteArticlesStatements(userId)...)
+	}
+
+	statements = append(statements, []statement{
+		{`DELETE FROM follows WHERE follower_id = ? OR followee_id = ?`, []interface{}{userId, userId}},
+		{`DELETE FROM notifications WHERE user_id = ? OR actor_id = ?`, []interface{}{userId, userId}},
+		{`DELETE FROM refresh_tokens WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM sessions WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM revoked_tokens WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM password_resets WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM mfa_recovery_codes WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM user_identities WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM personal_access_tokens WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM data_exports WHERE user_id = ?`, []interface{}{userId}},
+		{`DELETE FROM login_attempts WHERE scope = ? AND attempt_key = LOWER(?)`, []interface{}{models.LoginScopeAccount, email}},
+		{
+			`UPDATE users SET
+				username = CONCAT(?, id),
+				email = CONCAT('deleted-', id, '@deleted.invalid'),
+				password = '',
+				role = ?,
+				email_verified_at = NULL,
+				verification_sent_at = NULL,
+				display_name = NULL,
+				bio = NULL,
+				avatar_url = NULL,
+				mfa_secret = NULL,
+				mfa_enabled_at = NULL,
+				mfa_last_step = 0,
+				token_version = token_version + 1,
+				deletion_scheduled_at = NULL,
+				deleted_at = CURRENT_TIMESTAMP
+			 WHERE id = ?`,
+			[]interface{}{models.DeletedUsernamePrefix, models.RoleUser, userId},
+		},
+	}...)
+
+	for _, stmt := range statements {
+		_, err = tx.ExecContext(ctx, stmt.query, stmt.args...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+		}
+	}
+
+	for _, commentId := range votedComments {
+		err = refreshCommentScore(ctx, tx, commentId)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDeletingAccount, err)
+	}
+
+	return paths, nil
+}
+
+func deleteArticlesStatements(userId int) []statement {
+	const articleIds = `SELECT id FROM articles WHERE user_id = ?`
+	const commentIds = `SELECT c.id FROM comments c JOIN articles a ON a.id = c.article_id WHERE a.user_id = ?`
+
+	return []statement{
+		{`DELETE FROM comment_edits WHERE comment_id IN (` + commentIds + `)`, []interface{}{userId}},
+		{`DELETE FROM comment_votes WHERE comment_id IN (` + commentIds + `)`, []interface{}{userId}},
+		{
+			`DELETE FROM mentions
+			 WHERE (source_type = ? AND source_id IN (` + commentIds + `))
+			    OR (source_type = ? AND source_id IN (` + articleIds + `))`,
+			[]interface{}{models.MentionSourceComment, userId, models.MentionSourceArticle, userId},
+		},
+		{
+			`DELETE FROM notifications
+			 WHERE (source_type = ? AND source_id IN (` + commentIds + `))
+			    OR (source_type = ? AND source_id IN (` + articleIds + `))`,
+			[]interface{}{models.MentionSourceComment, userId, models.MentionSourceArticle, userId},
+		},
+		{`DELETE FROM comments WHERE article_id IN (` + articleIds + `) ORDER BY depth DESC`, []interface{}{userId}},
+		{`DELETE FROM comment_settings WHERE article_id IN (` + articleIds + `)`, []interface{}{userId}},
+		{`DELETE FROM reactions WHERE article_id IN (` + articleIds + `)`, []interface{}{userId}},
+		{`DELETE FROM articles WHERE user_id = ?`, []interface{}{userId}},
+	}
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"restapp/config"
+	"restapp/internal/database"
+	"restapp/internal/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// testDB connects to the MySQL database described by the TEST_DB_* variables
+// and skips the test when TEST_DB_HOST is not set.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	if os.Getenv("TEST_DB_HOST") == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+
+	cfg := &config.Config{}
+	cfg.Database.Host = os.Getenv("TEST_DB_HOST")
+	cfg.Database.Port = envOr("TEST_DB_PORT", "3306")
+	cfg.Database.Username = envOr("TEST_DB_USER", "root")
+	cfg.Database.Password = os.Getenv("TEST_DB_PASSWORD")
+	cfg.Database.DBName = envOr("TEST_DB_NAME", "restapp_test")
+	cfg.Database.ParseTime = true
+
+	err := database.InitDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return database.GetDB()
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func createUser(t *testing.T, db *sqlx.DB, name string) int {
+	t.Helper()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	result, err := db.Exec(
+		`INSERT INTO users (username, password, email, role) VALUES (?, '', ?, ?)`,
+		name+"-"+suffix,
+		name+"-"+suffix+"@example.com",
+		models.RoleAuthor,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := result.LastInsertId()
+	return int(id)
+}
+
+func TestPurgeAccountRemovesCommentVotes(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	authorId := createUser(t, db, "author")
+	voterId := createUser(t, db, "voter")
+	purgedId := createUser(t, db, "purged")
+
+	result, err := db.Exec(`INSERT INTO articles (user_id, title, content) VALUES (?, 'Purge', 'Body')`, authorId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	articleId, _ := result.LastInsertId()
+
+	comments := NewCommentRepository(db)
+	comment := models.Comment{
+		UserId:    authorId,
+		Content:   "Vote on me",
+		Status:    models.CommentStatusApproved,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	err = comments.CreateComment(ctx, &comment, int(articleId))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = comments.SetVote(ctx, comment.Id, voterId, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = comments.SetVote(ctx, comment.Id, purgedId, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`UPDATE users SET deletion_scheduled_at = DATE_SUB(CURRENT_TIMESTAMP, INTERVAL 1 DAY) WHERE id = ?`, purgedId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewAccountRepository(db).PurgeAccount(ctx, purgedId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var votes int
+	err = db.Get(&votes, `SELECT COUNT(*) FROM comment_votes WHERE user_id = ?`, purgedId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if votes != 0 {
+		t.Errorf("purged user still has %d comment votes", votes)
+	}
+
+	updated, err := comments.GetCommentById(ctx, comment.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Upvotes != 0 || updated.Downvotes != 1 {
+		t.Errorf("votes = +%d/-%d, want +0/-1", updated.Upvotes, updated.Downvotes)
+	}
+	if updated.Score != models.WilsonScore(0, 1) {
+		t.Errorf("score = %v, want %v", updated.Score, models.WilsonScore(0, 1))
+	}
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"restapp/internal/messages"
 	"restapp/internal/models"
@@ -14,11 +15,13 @@ import (
 type ArticleRepositoryInterface interface {
 	GetAllArticles(ctx context.Context) (*[]models.Article, error)
 	GetById(ctx context.Context, id int) (*models.Article, error)
+	GetFollowedArticles(ctx context.Context, userId int, afterId int, limit int) ([]models.Article, error)
 	StoreArticle(ctx context.Context, article *models.Article, userId int) error
 	UpdateArticle(ctx context.Context, id int, article *models.Article) error
 	DeleteArticle(ctx context.Context, id int) error
-	LikeArticle(ctx context.Context, articleId int, userId int) error
-	UnlikeArticle(ctx context.Context, articleId int, userId int) error
+	React(ctx context.Context, articleId int, userId int, reactionType string) (int, error)
+	Unreact(ctx context.Context, articleId int, userId int, reactionType string) (int, error)
+	ReconcileCounters(ctx context.Context) (int64, error)
 }
 
 type ArticleRepository struct {
@@ -35,14 +38,16 @@ func (r *ArticleRepository) GetAllArticles(ctx context.Context) (*[]models.Artic
 
 	var articles []models.Article
 	err := r.db.SelectContext(ctx, &articles, `
-		SELECT a.id, a.title, a.content, COUNT(l.id) as likes, a.created_at, a.updated_at
-		FROM articles a
-		LEFT JOIN likes l ON a.id = l.article_id
-		GROUP BY a.id, a.title, a.content, a.created_at, a.updated_at;
+		SELECT id, user_id, title, content, likes_count, comments_count, created_at, updated_at FROM articles
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", messages.ErrFetchArticles, err)
 	}
+
+	err = r.loadReactions(ctx, articles)
+	if err != nil {
+		return nil, err
+	}
 	return &articles, nil
 }
 
@@ -52,7 +57,8 @@ func (r *ArticleRepository) GetById(ctx context.Context, id int) (*models.Articl
 
 	var article models.Article
 	err := r.db.GetContext(ctx, &article, `
-		SELECT id, title, content, created_at, updated_at FROM articles WHERE id = ?
+		SELECT id, user_id, title, content, likes_count, comments_count, created_at, updated_at
+		FROM articles WHERE id = ?
 	`, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -60,14 +66,104 @@ func (r *ArticleRepository) GetById(ctx context.Context, id int) (*models.Articl
 		}
 		return nil, fmt.Errorf("%w: %v", messages.ErrFetchArticles, err)
 	}
+
+	var counts []models.ReactionCount
+	err = r.db.SelectContext(ctx, &counts, `
+		SELECT article_id, type, COUNT(*) AS count
+		FROM reactions
+		WHERE article_id = ? AND type <> ?
+		GROUP BY article_id, type
+	`, id, models.ReactionLike)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrFetchArticles, err)
+	}
+
+	reactions := make(map[string]int)
+	for _, count := range counts {
+		reactions[count.Type] = count.Count
+	}
+	setReactions(&article, reactions)
+
 	return &article, nil
 }
 
+func (r *ArticleRepository) GetFollowedArticles(ctx context.Context, userId int, afterId int, limit int) ([]models.Article, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `SELECT a.id, a.user_id, a.title, a.content, a.likes_count, a.comments_count, a.created_at, a.updated_at
+		 FROM articles a
+		 JOIN follows f ON f.followee_id = a.user_id
+		 WHERE f.follower_id = ?`
+	args := []interface{}{userId}
+
+	if afterId > 0 {
+		query += ` AND a.id < ?`
+		args = append(args, afterId)
+	}
+
+	query += ` ORDER BY a.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	articles := []models.Article{}
+	err := r.db.SelectContext(ctx, &articles, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrFetchArticles, err)
+	}
+
+	err = r.loadReactions(ctx, articles)
+	if err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// loadReactions fills in the non-like reaction counts of the given articles.
+func (r *ArticleRepository) loadReactions(ctx context.Context, articles []models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(articles))
+	for i, article := range articles {
+		ids[i] = article.Id
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT article_id, type, COUNT(*) AS count
+		FROM reactions
+		WHERE article_id IN (?) AND type <> ?
+		GROUP BY article_id, type
+	`, ids, models.ReactionLike)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrFetchArticles, err)
+	}
+
+	var counts []models.ReactionCount
+	err = r.db.SelectContext(ctx, &counts, r.db.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrFetchArticles, err)
+	}
+
+	byArticle := make(map[int]map[string]int)
+	for _, count := range counts {
+		if byArticle[count.ArticleId] == nil {
+			byArticle[count.ArticleId] = make(map[string]int)
+		}
+		byArticle[count.ArticleId][count.Type] = count.Count
+	}
+
+	for i := range articles {
+		setReactions(&articles[i], byArticle[articles[i].Id])
+	}
+	return nil
+}
+
 func (r *ArticleRepository) StoreArticle(ctx context.Context, article *models.Article, userId int) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, `
+	result, err := r.db.ExecContext(ctx, `
 		INSERT INTO articles (user_id, title, content, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?)`,
 		userId, article.Title, article.Content, article.CreatedAt, article.UpdatedAt,
@@ -75,6 +171,14 @@ func (r *ArticleRepository) StoreArticle(ctx context.Context, article *models.Ar
 	if err != nil {
 		return fmt.Errorf("%w: %v", messages.ErrInvalidArticleData, err)
 	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	article.Id = int(id)
+	article.UserId = userId
 	return nil
 }
 
@@ -119,43 +223,169 @@ func (r *ArticleRepository) DeleteArticle(ctx context.Context, id int) error {
 	return nil
 }
 
-func (r *ArticleRepository) LikeArticle(ctx context.Context, articleId int, userId int) error {
+func (r *ArticleRepository) React(ctx context.Context, articleId int, userId int, reactionType string) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var exists bool
-	err := r.db.GetContext(ctx, &exists, `
-		SELECT EXISTS (
-			SELECT 1 FROM likes WHERE article_id = ? AND user_id = ?
-		)
-	`, articleId, userId)
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
 	}
-	if exists {
-		return messages.ErrLikeExists
+	defer tx.Rollback()
+
+	err = lockArticle(ctx, tx, articleId)
+	if err != nil {
+		return 0, err
 	}
 
-	_, err = r.db.ExecContext(ctx, `
-		INSERT INTO likes (article_id, user_id) VALUES (?, ?)`,
-		articleId, userId,
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO reactions (article_id, user_id, type) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = id`,
+		articleId, userId, reactionType,
 	)
 	if err != nil {
-		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
 	}
-	return nil
+
+	if reactionType == models.ReactionLike {
+		err = adjustLikesCount(ctx, tx, articleId, result, 1)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	count, err := countReactions(ctx, tx, articleId, reactionType)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return count, nil
 }
 
-func (r *ArticleRepository) UnlikeArticle(ctx context.Context, articleId int, userId int) error {
+func (r *ArticleRepository) Unreact(ctx context.Context, articleId int, userId int, reactionType string) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, `
-		DELETE FROM likes WHERE article_id = ? AND user_id = ?`,
-		articleId, userId,
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	err = lockArticle(ctx, tx, articleId)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM reactions WHERE article_id = ? AND user_id = ? AND type = ?`,
+		articleId, userId, reactionType,
 	)
 	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	if reactionType == models.ReactionLike {
+		err = adjustLikesCount(ctx, tx, articleId, result, -1)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	count, err := countReactions(ctx, tx, articleId, reactionType)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return count, nil
+}
+
+func lockArticle(ctx context.Context, tx *sqlx.Tx, articleId int) error {
+	var id int
+	err := tx.GetContext(ctx, &id, `SELECT id FROM articles WHERE id = ? FOR UPDATE`, articleId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return messages.ErrArticleNotFound
+		}
 		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
 	}
 	return nil
 }
+
+func adjustLikesCount(ctx context.Context, tx *sqlx.Tx, articleId int, result sql.Result, delta int) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE articles SET likes_count = likes_count + ? WHERE id = ?`,
+		delta, articleId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return nil
+}
+
+func countReactions(ctx context.Context, tx *sqlx.Tx, articleId int, reactionType string) (int, error) {
+	query := `SELECT COUNT(*) FROM reactions WHERE article_id = ? AND type = ?`
+	args := []interface{}{articleId, reactionType}
+	if reactionType == models.ReactionLike {
+		query = `SELECT likes_count FROM articles WHERE id = ?`
+		args = []interface{}{articleId}
+	}
+
+	var count int
+	err := tx.GetContext(ctx, &count, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return count, nil
+}
+
+func (r *ArticleRepository) ReconcileCounters(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE articles a
+		LEFT JOIN (
+			SELECT article_id, COUNT(*) AS total FROM reactions WHERE type = ? GROUP BY article_id
+		) l ON l.article_id = a.id
+		LEFT JOIN (
+			SELECT article_id, COUNT(*) AS total FROM comments
+			WHERE deleted_at IS NULL AND status = 'approved' GROUP BY article_id
+		) c ON c.article_id = a.id
+		SET a.likes_count = COALESCE(l.total, 0), a.comments_count = COALESCE(c.total, 0)
+		WHERE a.likes_count <> COALESCE(l.total, 0) OR a.comments_count <> COALESCE(c.total, 0)`,
+		models.ReactionLike,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected, nil
+}
+
+func setReactions(article *models.Article, reactions map[string]int) {
+	if reactions == nil {
+		reactions = make(map[string]int)
+	}
+	if article.LikesCount > 0 {
+		reactions[models.ReactionLike] = article.LikesCount
+	}
+	article.Reactions = reactions
+}
@@ -5,11 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"restapp/internal/messages"
 	"restapp/internal/models"
+	"strings"
 )
 
+const errDuplicateEntry = 1062
+
 type AuthRepositoryInterface interface {
 	Register(ctx context.Context, user *models.User) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
@@ -24,6 +28,10 @@ func NewAuthRepository(db *sqlx.DB) *AuthRepository {
 }
 
 func (r *AuthRepository) Register(ctx context.Context, user *models.User) (*models.User, error) {
+	if strings.HasPrefix(strings.ToLower(user.Username), models.DeletedUsernamePrefix) {
+		return nil, messages.ErrUsernameReserved
+	}
+
 	registeredUser, err := r.db.ExecContext(
 		ctx,
 		`INSERT INTO users (username, password, email, role, created_at, updated_at)
@@ -36,6 +44,9 @@ func (r *AuthRepository) Register(ctx context.Context, user *models.User) (*mode
 		user.UpdatedAt,
 	)
 	if err != nil {
+		if conflict := duplicateUserField(err); conflict != nil {
+			return nil, conflict
+		}
 		return nil, fmt.Errorf("%w: %v", messages.ErrCreatingUser, err)
 	}
 
@@ -54,16 +65,31 @@ func (r *AuthRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 
 	err := r.db.GetContext(ctx,
 		&user,
-		`SELECT id, username, password, email, role, created_at, updated_at 
-		 FROM users WHERE email = ?`,
+		`SELECT id, username, password, email, role, email_verified_at, mfa_enabled_at, created_at, updated_at
+		 FROM users WHERE LOWER(email) = LOWER(?)`,
 		email,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, fmt.Errorf("%w: %s", messages.ErrUserNotFound, email)
 		}
 		return nil, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
 	}
 
 	return &user, nil
 }
+
+func duplicateUserField(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != errDuplicateEntry {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(mysqlErr.Message, "uq_users_email"):
+		return messages.ErrEmailAlreadyExists
+	case strings.Contains(mysqlErr.Message, "uq_users_username"):
+		return messages.ErrUsernameAlreadyExists
+	}
+	return nil
+}
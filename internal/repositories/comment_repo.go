@@ -2,16 +2,35 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"restapp/internal/messages"
 	"restapp/internal/models"
+	"strings"
 	"time"
 )
 
+const repliesCount = `(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND r.status = 'approved' AND r.deleted_at IS NULL)`
+
+const commentColumns = `c.id, c.article_id, c.user_id, c.parent_id, c.depth, c.path, c.content,
+	c.edited_at, c.deleted_at, c.status, c.upvotes, c.downvotes, c.score, ` + repliesCount + ` AS replies_count, c.created_at, c.updated_at`
+
 type CommentRepositoryInterface interface {
 	CreateComment(ctx context.Context, comment *models.Comment, articleId int) error
-	GetAllComments(ctx context.Context, articleId int) ([]models.Comment, error)
+	GetCommentById(ctx context.Context, id int) (*models.Comment, error)
+	GetThreads(ctx context.Context, articleId int, sort string, after *models.CommentCursor, limit int) ([]models.Comment, error)
+	GetDescendants(ctx context.Context, articleId int, paths []string, maxDepth int, limit int) ([]models.Comment, error)
+	UpdateComment(ctx context.Context, comment *models.Comment, editorId int) error
+	DeleteComment(ctx context.Context, comment *models.Comment, editorId int) error
+	GetCommentHistory(ctx context.Context, commentId int) ([]models.CommentEdit, error)
+	SetVote(ctx context.Context, commentId, userId, value int) error
+	GetUserVotes(ctx context.Context, userId int, commentIds []int) (map[int]int, error)
+	GetSettings(ctx context.Context, articleId int) (*models.CommentSettings, error)
+	SaveSettings(ctx context.Context, settings *models.CommentSettings) error
+	GetPendingComments(ctx context.Context, articleId int) ([]models.Comment, error)
+	SetStatus(ctx context.Context, comment *models.Comment, status string) error
 }
 
 type CommentRepository struct {
@@ -26,13 +45,27 @@ func (r *CommentRepository) CreateComment(ctx context.Context, comment *models.C
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := r.db.ExecContext(
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
+	}
+	defer tx.Rollback()
+
+	err = lockArticle(ctx, tx, articleId)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(
 		ctx,
-		`INSERT INTO comments (article_id, user_id, content, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?)`,
+		`INSERT INTO comments (article_id, user_id, parent_id, depth, content, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 		articleId,
 		comment.UserId,
+		comment.ParentId,
+		comment.Depth,
 		comment.Content,
+		comment.Status,
 		comment.CreatedAt,
 		comment.UpdatedAt,
 	)
@@ -40,22 +73,447 @@ func (r *CommentRepository) CreateComment(ctx context.Context, comment *models.C
 		return fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
 	}
 
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
+	}
+
+	path := fmt.Sprintf("%010d", id)
+	if comment.Path != "" {
+		path = comment.Path + "/" + path
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE comments SET path = ? WHERE id = ?`, path, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
+	}
+
+	if comment.Status == models.CommentStatusApproved {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE articles SET comments_count = comments_count + 1 WHERE id = ?`,
+			articleId,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
+	}
+
+	comment.Id = int(id)
+	comment.Path = path
+
 	return nil
 }
 
-func (r *CommentRepository) GetAllComments(ctx context.Context, articleId int) ([]models.Comment, error) {
+func (r *CommentRepository) GetCommentById(ctx context.Context, id int) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var comment models.Comment
+
+	err := r.db.GetContext(
+		ctx,
+		&comment,
+		`SELECT `+commentColumns+`
+		 FROM comments c
+		 WHERE c.id = ?`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	return &comment, nil
+}
+
+func (r *CommentRepository) GetThreads(ctx context.Context, articleId int, sort string, after *models.CommentCursor, limit int) ([]models.Comment, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	query := `SELECT ` + commentColumns + `
+		 FROM comments c
+		 WHERE c.article_id = ? AND c.parent_id IS NULL AND c.status = ?`
+	args := []interface{}{articleId, models.CommentStatusApproved}
+
+	switch sort {
+	case models.CommentSortNewest:
+		if after != nil {
+			query += ` AND c.id < ?`
+			args = append(args, after.Id)
+		}
+		query += ` ORDER BY c.id DESC`
+	case models.CommentSortTop:
+		if after != nil {
+			query += ` AND (c.score < ? OR (c.score = ? AND c.id < ?))`
+			args = append(args, after.Score, after.Score, after.Id)
+		}
+		query += ` ORDER BY c.score DESC, c.id DESC`
+	default:
+		if after != nil {
+			query += ` AND c.id > ?`
+			args = append(args, after.Id)
+		}
+		query += ` ORDER BY c.id`
+	}
+
+	query += ` LIMIT ?`
+	args = append(args, limit)
+
+	var comments []models.Comment
+
+	err := r.db.SelectContext(ctx, &comments, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepository) GetDescendants(ctx context.Context, articleId int, paths []string, maxDepth int, limit int) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	conditions := make([]string, len(paths))
+	args := []interface{}{articleId, models.CommentStatusApproved, maxDepth}
+	for i, path := range paths {
+		conditions[i] = "c.path LIKE ?"
+		args = append(args, path+"/%")
+	}
+	args = append(args, limit)
+
 	var comments []models.Comment
 
 	err := r.db.SelectContext(
 		ctx,
 		&comments,
-		`SELECT id, article_id, user_id, content, created_at, updated_at
-		 FROM comments
-		 WHERE article_id = ?`,
+		`SELECT `+commentColumns+`
+		 FROM comments c
+		 WHERE c.article_id = ? AND c.status = ? AND c.depth <= ? AND (`+strings.Join(conditions, " OR ")+`)
+		 ORDER BY c.path
+		 LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepository) UpdateComment(ctx context.Context, comment *models.Comment, editorId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+	defer tx.Rollback()
+
+	err = archiveComment(ctx, tx, comment.Id, editorId)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE comments SET content = ?, edited_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		comment.Content,
+		comment.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) DeleteComment(ctx context.Context, comment *models.Comment, editorId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+	}
+	defer tx.Rollback()
+
+	// Re-read the row under the lock so a concurrent delete is seen before the
+	// counter is touched.
+	var locked struct {
+		Status    string  `db:"status"`
+		DeletedAt *string `db:"deleted_at"`
+	}
+	err = tx.GetContext(ctx, &locked, `SELECT status, deleted_at FROM comments WHERE id = ? FOR UPDATE`, comment.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return messages.ErrCommentNotFound
+		}
+		return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+	}
+	if locked.DeletedAt != nil {
+		return messages.ErrCommentNotFound
+	}
+
+	var hasReplies bool
+	err = tx.GetContext(
+		ctx,
+		&hasReplies,
+		`SELECT EXISTS (SELECT 1 FROM comments WHERE parent_id = ?)`,
+		comment.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+	}
+
+	var result sql.Result
+	if hasReplies {
+		err = archiveComment(ctx, tx, comment.Id, editorId)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+		}
+
+		result, err = tx.ExecContext(
+			ctx,
+			`UPDATE comments SET content = ?, deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL`,
+			models.DeletedCommentContent,
+			comment.Id,
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM comment_edits WHERE comment_id = ?`, comment.Id)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM comment_votes WHERE comment_id = ?`, comment.Id)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+		}
+
+		result, err = tx.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, comment.Id)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+	}
+	if rowsAffected != 1 {
+		return messages.ErrCommentNotFound
+	}
+
+	if locked.Status == models.CommentStatusApproved {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE articles SET comments_count = comments_count - 1 WHERE id = ?`,
+			comment.ArticleId,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDeletingComment, err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) GetCommentHistory(ctx context.Context, commentId int) ([]models.CommentEdit, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var edits []models.CommentEdit
+
+	err := r.db.SelectContext(
+		ctx,
+		&edits,
+		`SELECT id, comment_id, editor_id, content, created_at
+		 FROM comment_edits
+		 WHERE comment_id = ?
+		 ORDER BY id DESC`,
+		commentId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	return edits, nil
+}
+
+func (r *CommentRepository) SetVote(ctx context.Context, commentId, userId, value int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrVotingComment, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.GetContext(ctx, &id, `SELECT id FROM comments WHERE id = ? FOR UPDATE`, commentId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return messages.ErrCommentNotFound
+		}
+		return fmt.Errorf("%w: %v", messages.ErrVotingComment, err)
+	}
+
+	if value == 0 {
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM comment_votes WHERE comment_id = ? AND user_id = ?`,
+			commentId,
+			userId,
+		)
+	} else {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO comment_votes (comment_id, user_id, value) VALUES (?, ?, ?)
+			 ON DUPLICATE KEY UPDATE value = VALUES(value)`,
+			commentId,
+			userId,
+			value,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrVotingComment, err)
+	}
+
+	err = refreshCommentScore(ctx, tx, commentId)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrVotingComment, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrVotingComment, err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) GetUserVotes(ctx context.Context, userId int, commentIds []int) (map[int]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	votes := make(map[int]int)
+	if len(commentIds) == 0 {
+		return votes, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT comment_id, value FROM comment_votes WHERE user_id = ? AND comment_id IN (?)`,
+		userId,
+		commentIds,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	var rows []struct {
+		CommentId int `db:"comment_id"`
+		Value     int `db:"value"`
+	}
+	err = r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	for _, row := range rows {
+		votes[row.CommentId] = row.Value
+	}
+	return votes, nil
+}
+
+func (r *CommentRepository) GetSettings(ctx context.Context, articleId int) (*models.CommentSettings, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var settings models.CommentSettings
+
+	err := r.db.GetContext(
+		ctx,
+		&settings,
+		`SELECT a.id AS article_id, a.user_id AS author_id,
+		        COALESCE(s.locked, FALSE) AS locked,
+		        COALESCE(s.audience, ?) AS audience,
+		        COALESCE(s.require_approval, FALSE) AS require_approval
+		 FROM articles a
+		 LEFT JOIN comment_settings s ON s.article_id = a.id
+		 WHERE a.id = ?`,
+		models.CommentAudienceEveryone,
+		articleId,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrArticleNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &settings, nil
+}
+
+func (r *CommentRepository) SaveSettings(ctx context.Context, settings *models.CommentSettings) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO comment_settings (article_id, locked, audience, require_approval)
+		 VALUES (?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE locked = VALUES(locked), audience = VALUES(audience),
+		 require_approval = VALUES(require_approval)`,
+		settings.ArticleId,
+		settings.Locked,
+		settings.Audience,
+		settings.RequireApproval,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrCommentSettings, err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) GetPendingComments(ctx context.Context, articleId int) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var comments []models.Comment
+
+	err := r.db.SelectContext(
+		ctx,
+		&comments,
+		`SELECT `+commentColumns+`
+		 FROM comments c
+		 WHERE c.article_id = ? AND c.status = ?
+		 ORDER BY c.id`,
 		articleId,
+		models.CommentStatusPending,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
@@ -63,3 +521,91 @@ func (r *CommentRepository) GetAllComments(ctx context.Context, articleId int) (
 
 	return comments, nil
 }
+
+func (r *CommentRepository) SetStatus(ctx context.Context, comment *models.Comment, status string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE comments SET status = ? WHERE id = ? AND status = ?`,
+		status,
+		comment.Id,
+		models.CommentStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+	if rowsAffected == 0 {
+		return messages.ErrCommentNotFound
+	}
+
+	if status == models.CommentStatusApproved {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE articles SET comments_count = comments_count + 1 WHERE id = ?`,
+			comment.ArticleId,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingComment, err)
+	}
+
+	return nil
+}
+
+func archiveComment(ctx context.Context, tx *sqlx.Tx, commentId, editorId int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO comment_edits (comment_id, editor_id, content)
+		 SELECT id, ?, content FROM comments WHERE id = ?`,
+		editorId,
+		commentId,
+	)
+	return err
+}
+
+// refreshCommentScore recomputes a comment's vote tally and score from
+// comment_votes.
+func refreshCommentScore(ctx context.Context, tx *sqlx.Tx, commentId int) error {
+	var tally struct {
+		Upvotes   int `db:"upvotes"`
+		Downvotes int `db:"downvotes"`
+	}
+	err := tx.GetContext(
+		ctx,
+		&tally,
+		`SELECT COALESCE(SUM(value = 1), 0) AS upvotes, COALESCE(SUM(value = -1), 0) AS downvotes
+		 FROM comment_votes WHERE comment_id = ?`,
+		commentId,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE comments SET upvotes = ?, downvotes = ?, score = ? WHERE id = ?`,
+		tally.Upvotes,
+		tally.Downvotes,
+		models.WilsonScore(tally.Upvotes, tally.Downvotes),
+		commentId,
+	)
+	return err
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type FollowRepositoryInterface interface {
+	Follow(ctx context.Context, followerId, followeeId int) (bool, error)
+	Unfollow(ctx context.Context, followerId, followeeId int) (bool, error)
+	IsFollowing(ctx context.Context, followerId, followeeId int) (bool, error)
+	GetFollowers(ctx context.Context, userId int, after *models.FollowCursor, limit int) ([]models.FollowUser, error)
+	GetFollowing(ctx context.Context, userId int, after *models.FollowCursor, limit int) ([]models.FollowUser, error)
+}
+
+type FollowRepository struct {
+	db *sqlx.DB
+}
+
+func NewFollowRepository(db *sqlx.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+func (r *FollowRepository) Follow(ctx context.Context, followerId, followeeId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT IGNORE INTO follows (follower_id, followee_id) VALUES (?, ?)`,
+		followerId,
+		followeeId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrFollowing, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *FollowRepository) Unfollow(ctx context.Context, followerId, followeeId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM follows WHERE follower_id = ? AND followee_id = ?`,
+		followerId,
+		followeeId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrFollowing, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *FollowRepository) IsFollowing(ctx context.Context, followerId, followeeId int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var following bool
+	err := r.db.GetContext(
+		ctx,
+		&following,
+		`SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = ? AND followee_id = ?)`,
+		followerId,
+		followeeId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return following, nil
+}
+
+func (r *FollowRepository) GetFollowers(ctx context.Context, userId int, after *models.FollowCursor, limit int) ([]models.FollowUser, error) {
+	return r.getFollowUsers(ctx, "followee_id", "follower_id", userId, after, limit)
+}
+
+func (r *FollowRepository) GetFollowing(ctx context.Context, userId int, after *models.FollowCursor, limit int) ([]models.FollowUser, error) {
+	return r.getFollowUsers(ctx, "follower_id", "followee_id", userId, after, limit)
+}
+
+func (r *FollowRepository) getFollowUsers(ctx context.Context, matchColumn, userColumn string, userId int, after *models.FollowCursor, limit int) ([]models.FollowUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `SELECT u.id, u.username, u.display_name, u.avatar_url, f.created_at AS followed_at
+		 FROM follows f
+		 JOIN users u ON u.id = f.` + userColumn + `
+		 WHERE f.` + matchColumn + ` = ?`
+	args := []interface{}{userId}
+
+	if after != nil {
+		query += ` AND (f.created_at < ? OR (f.created_at = ? AND u.id < ?))`
+		args = append(args, after.FollowedAt, after.FollowedAt, after.Id)
+	}
+
+	query += ` ORDER BY f.created_at DESC, u.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	users := []models.FollowUser{}
+	err := r.db.SelectContext(ctx, &users, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return users, nil
+}
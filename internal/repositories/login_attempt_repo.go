@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type LoginAttemptRepositoryInterface interface {
+	GetThrottle(ctx context.Context, scope, key string) (*models.LoginThrottle, error)
+	RecordFailure(ctx context.Context, scope, key string, maxAttempts, window, lockout int) error
+	Reset(ctx context.Context, scope, key string) error
+}
+
+type LoginAttemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptRepository(db *sqlx.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+func (r *LoginAttemptRepository) GetThrottle(ctx context.Context, scope, key string) (*models.LoginThrottle, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var throttle models.LoginThrottle
+	err := r.db.GetContext(
+		ctx,
+		&throttle,
+		`SELECT failures,
+		        GREATEST(COALESCE(TIMESTAMPDIFF(SECOND, CURRENT_TIMESTAMP, locked_until), 0), 0) AS locked_for,
+		        TIMESTAMPDIFF(SECOND, last_failed_at, CURRENT_TIMESTAMP) AS since_failure
+		 FROM login_attempts
+		 WHERE scope = ? AND attempt_key = ?`,
+		scope,
+		key,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.LoginThrottle{}, nil
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &throttle, nil
+}
+
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, scope, key string, maxAttempts, window, lockout int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO login_attempts (scope, attempt_key, failures, last_failed_at)
+		 VALUES (?, ?, 1, CURRENT_TIMESTAMP)
+		 ON DUPLICATE KEY UPDATE
+			failures = IF(last_failed_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND), 1, failures + 1),
+			last_failed_at = CURRENT_TIMESTAMP`,
+		scope,
+		key,
+		window,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE login_attempts
+		 SET locked_until = DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND), failures = 0
+		 WHERE scope = ? AND attempt_key = ? AND failures >= ?`,
+		lockout,
+		scope,
+		key,
+		maxAttempts,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
+
+func (r *LoginAttemptRepository) Reset(ctx context.Context, scope, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE scope = ? AND attempt_key = ?`, scope, key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
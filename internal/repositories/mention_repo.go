@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type MentionRepositoryInterface interface {
+	GetUsersByUsernames(ctx context.Context, usernames []string) ([]models.User, error)
+	ReplaceMentions(ctx context.Context, sourceType string, sourceId, actorId int, mentions []models.Mention) error
+	GetMentions(ctx context.Context, sourceType string, sourceIds []int) ([]models.Mention, error)
+}
+
+type MentionRepository struct {
+	db *sqlx.DB
+}
+
+func NewMentionRepository(db *sqlx.DB) *MentionRepository {
+	return &MentionRepository{db: db}
+}
+
+func (r *MentionRepository) GetUsersByUsernames(ctx context.Context, usernames []string) ([]models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT id, username FROM users WHERE username IN (?)`, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+	}
+
+	var users []models.User
+	err = r.db.SelectContext(ctx, &users, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+	}
+
+	return users, nil
+}
+
+func (r *MentionRepository) ReplaceMentions(ctx context.Context, sourceType string, sourceId, actorId int, mentions []models.Mention) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrSavingMentions, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM mentions WHERE source_type = ? AND source_id = ?`,
+		sourceType,
+		sourceId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrSavingMentions, err)
+	}
+
+	for _, mention := range mentions {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO mentions (source_type, source_id, user_id, position, length)
+			 VALUES (?, ?, ?, ?, ?)`,
+			sourceType,
+			sourceId,
+			mention.UserId,
+			mention.Offset,
+			mention.Length,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrSavingMentions, err)
+		}
+
+		if mention.UserId == actorId {
+			continue
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT IGNORE INTO notifications (user_id, actor_id, type, source_type, source_id)
+			 VALUES (?, ?, ?, ?, ?)`,
+			mention.UserId,
+			actorId,
+			models.NotificationMention,
+			sourceType,
+			sourceId,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrSavingMentions, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrSavingMentions, err)
+	}
+
+	return nil
+}
+
+func (r *MentionRepository) GetMentions(ctx context.Context, sourceType string, sourceIds []int) ([]models.Mention, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if len(sourceIds) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT m.source_type, m.source_id, m.user_id, u.username, m.position, m.length
+		 FROM mentions m
+		 JOIN users u ON u.id = m.user_id
+		 WHERE m.source_type = ? AND m.source_id IN (?)
+		 ORDER BY m.source_id, m.position`,
+		sourceType,
+		sourceIds,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingMentions, err)
+	}
+
+	var mentions []models.Mention
+	err = r.db.SelectContext(ctx, &mentions, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingMentions, err)
+	}
+
+	return mentions, nil
+}
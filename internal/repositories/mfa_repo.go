@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"time"
+)
+
+type MfaRepositoryInterface interface {
+	SetSecret(ctx context.Context, userId int, secret string) error
+	Enable(ctx context.Context, userId int, codeHashes []string) error
+	Disable(ctx context.Context, userId int) error
+	UseStep(ctx context.Context, userId int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error)
+}
+
+type MfaRepository struct {
+	db *sqlx.DB
+}
+
+func NewMfaRepository(db *sqlx.DB) *MfaRepository {
+	return &MfaRepository{db: db}
+}
+
+func (r *MfaRepository) SetSecret(ctx context.Context, userId int, secret string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET mfa_secret = ? WHERE id = ? AND mfa_enabled_at IS NULL`,
+		secret,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if rowsAffected == 0 {
+		return messages.ErrMfaAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *MfaRepository) Enable(ctx context.Context, userId int, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE users SET mfa_enabled_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND mfa_enabled_at IS NULL AND mfa_secret IS NOT NULL`,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if rowsAffected == 0 {
+		return messages.ErrMfaAlreadyEnabled
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userId, codeHashes)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return nil
+}
+
+func (r *MfaRepository) Disable(ctx context.Context, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET mfa_secret = NULL, mfa_enabled_at = NULL, mfa_last_step = 0 WHERE id = ?`,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userId, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return nil
+}
+
+func (r *MfaRepository) UseStep(ctx context.Context, userId int, step int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET mfa_last_step = ? WHERE id = ? AND mfa_last_step < ?`,
+		step,
+		userId,
+		step,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *MfaRepository) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE mfa_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		 WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		userId,
+		codeHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userId int, codeHashes []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = ?`, userId)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	for _, hash := range codeHashes {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)`,
+			userId,
+			hash,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+		}
+	}
+	return nil
+}
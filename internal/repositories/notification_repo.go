@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type NotificationRepositoryInterface interface {
+	GetNotifications(ctx context.Context, userId int, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(ctx context.Context, id, userId int) error
+}
+
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) GetNotifications(ctx context.Context, userId int, unreadOnly bool) ([]models.Notification, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `SELECT id, user_id, actor_id, type, source_type, source_id, read_at, created_at
+		 FROM notifications
+		 WHERE user_id = ?`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY id DESC LIMIT 100`
+
+	var notifications []models.Notification
+	err := r.db.SelectContext(ctx, &notifications, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingNotifications, err)
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := r.db.GetContext(
+		ctx,
+		&exists,
+		`SELECT EXISTS (SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)`,
+		id,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if !exists {
+		return messages.ErrNotificationNotFound
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND read_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return nil
+}
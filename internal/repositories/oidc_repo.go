@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type OIDCRepositoryInterface interface {
+	CreateState(ctx context.Context, state *models.OIDCState, ttl int) error
+	ConsumeState(ctx context.Context, state string) (*models.OIDCState, error)
+	GetIdentityUser(ctx context.Context, provider, subject string) (int, error)
+	LinkIdentity(ctx context.Context, userId int, provider, subject, email string) error
+}
+
+type OIDCRepository struct {
+	db *sqlx.DB
+}
+
+func NewOIDCRepository(db *sqlx.DB) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) CreateState(ctx context.Context, state *models.OIDCState, ttl int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oidc_states WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO oidc_states (state, provider, code_verifier, nonce, expires_at)
+		 VALUES (?, ?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND))`,
+		state.State,
+		state.Provider,
+		state.CodeVerifier,
+		state.Nonce,
+		ttl,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
+
+func (r *OIDCRepository) ConsumeState(ctx context.Context, state string) (*models.OIDCState, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	var stored models.OIDCState
+	err = tx.GetContext(
+		ctx,
+		&stored,
+		`SELECT state, provider, code_verifier, nonce FROM oidc_states
+		 WHERE state = ? AND expires_at >= CURRENT_TIMESTAMP
+		 FOR UPDATE`,
+		state,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM oidc_states WHERE state = ?`, state)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return &stored, nil
+}
+
+func (r *OIDCRepository) GetIdentityUser(ctx context.Context, provider, subject string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var userId int
+	err := r.db.GetContext(
+		ctx,
+		&userId,
+		`SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`,
+		provider,
+		subject,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, messages.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return userId, nil
+}
+
+func (r *OIDCRepository) LinkIdentity(ctx context.Context, userId int, provider, subject, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)`,
+		userId,
+		provider,
+		subject,
+		email,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
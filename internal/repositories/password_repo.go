@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"time"
+)
+
+type PasswordRepositoryInterface interface {
+	CreateResetToken(ctx context.Context, userId int, tokenHash string, ttl int) error
+	ConsumeResetToken(ctx context.Context, tokenHash string) (int, error)
+}
+
+type PasswordRepository struct {
+	db *sqlx.DB
+}
+
+func NewPasswordRepository(db *sqlx.DB) *PasswordRepository {
+	return &PasswordRepository{db: db}
+}
+
+func (r *PasswordRepository) CreateResetToken(ctx context.Context, userId int, tokenHash string, ttl int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO password_resets (user_id, token_hash, expires_at)
+		 VALUES (?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND))`,
+		userId,
+		tokenHash,
+		ttl,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrStoringResetToken, err)
+	}
+
+	return nil
+}
+
+func (r *PasswordRepository) ConsumeResetToken(ctx context.Context, tokenHash string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	defer tx.Rollback()
+
+	var userId int
+	err = tx.GetContext(
+		ctx,
+		&userId,
+		`SELECT user_id FROM password_resets
+		 WHERE token_hash = ? AND used_at IS NULL AND expires_at >= CURRENT_TIMESTAMP
+		 FOR UPDATE`,
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, messages.ErrInvalidResetToken
+		}
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE password_resets SET used_at = CURRENT_TIMESTAMP
+		 WHERE user_id = ? AND used_at IS NULL`,
+		userId,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return userId, nil
+}
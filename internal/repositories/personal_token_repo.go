@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"strings"
+	"time"
+)
+
+type PersonalTokenRepositoryInterface interface {
+	CreatePersonalToken(ctx context.Context, token *models.PersonalToken, tokenHash string, expiresInDays *int) error
+	GetPersonalTokens(ctx context.Context, userId int) ([]models.PersonalToken, error)
+	GetActivePersonalToken(ctx context.Context, tokenHash string) (*models.PersonalToken, error)
+	TouchPersonalToken(ctx context.Context, id int) error
+	RevokePersonalToken(ctx context.Context, id, userId int) error
+}
+
+type PersonalTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewPersonalTokenRepository(db *sqlx.DB) *PersonalTokenRepository {
+	return &PersonalTokenRepository{db: db}
+}
+
+func (r *PersonalTokenRepository) CreatePersonalToken(ctx context.Context, token *models.PersonalToken, tokenHash string, expiresInDays *int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, token_version, expires_at)
+		 SELECT id, ?, ?, ?, ?, token_version, IF(? IS NULL, NULL, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? DAY))
+		 FROM users WHERE id = ?`,
+		token.Name,
+		token.TokenPrefix,
+		tokenHash,
+		strings.Join(token.Scopes, ","),
+		expiresInDays,
+		expiresInDays,
+		token.UserId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return r.db.GetContext(
+		ctx,
+		token,
+		`SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		 FROM personal_access_tokens WHERE id = ?`,
+		id,
+	)
+}
+
+func (r *PersonalTokenRepository) GetPersonalTokens(ctx context.Context, userId int) ([]models.PersonalToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tokens := []models.PersonalToken{}
+	err := r.db.SelectContext(
+		ctx,
+		&tokens,
+		`SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		 FROM personal_access_tokens
+		 WHERE user_id = ? AND revoked_at IS NULL
+		 ORDER BY id DESC`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	for i := range tokens {
+		tokens[i].Scopes = strings.Split(tokens[i].ScopeList, ",")
+	}
+	return tokens, nil
+}
+
+func (r *PersonalTokenRepository) GetActivePersonalToken(ctx context.Context, tokenHash string) (*models.PersonalToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var token models.PersonalToken
+	err := r.db.GetContext(
+		ctx,
+		&token,
+		`SELECT t.id, t.user_id, u.role, t.name, t.token_prefix, t.scopes, t.token_version, t.expires_at, t.last_used_at, t.created_at
+		 FROM personal_access_tokens t
+		 JOIN users u ON u.id = t.user_id AND u.deleted_at IS NULL AND u.deletion_scheduled_at IS NULL
+		 WHERE t.token_hash = ? AND t.revoked_at IS NULL
+		   AND (t.expires_at IS NULL OR t.expires_at > CURRENT_TIMESTAMP)`,
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	token.Scopes = strings.Split(token.ScopeList, ",")
+	return &token, nil
+}
+
+func (r *PersonalTokenRepository) TouchPersonalToken(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE personal_access_tokens SET last_used_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND (last_used_at IS NULL OR last_used_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL 1 MINUTE))`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
+
+func (r *PersonalTokenRepository) RevokePersonalToken(ctx context.Context, id, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE personal_access_tokens SET revoked_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		id,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if rowsAffected == 0 {
+		return messages.ErrTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+	"unicode/utf8"
+)
+
+type TokenRepositoryInterface interface {
+	CreateRefreshToken(ctx context.Context, userId int, familyId, tokenHash string, ttl int) error
+	RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, ttl int) (*models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, tokenHash string, userId int) error
+	RevokeToken(ctx context.Context, jti string, userId int, expiresAt int64) error
+	GetRevokedTokens(ctx context.Context) ([]models.RevokedToken, error)
+	PruneRevokedTokens(ctx context.Context) (int64, error)
+	GetTokenVersion(ctx context.Context, userId int) (int, error)
+	BumpTokenVersion(ctx context.Context, userId int) (int, error)
+	CreateSession(ctx context.Context, sessionId string, userId int, client models.ClientInfo) error
+	GetSessions(ctx context.Context, userId int) ([]models.Session, error)
+	RevokeSession(ctx context.Context, sessionId string, userId int) error
+	TouchSession(ctx context.Context, sessionId string, interval int) error
+	GetRevokedSessions(ctx context.Context, ttl int) ([]models.RevokedToken, error)
+}
+
+type TokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepository(db *sqlx.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, userId int, familyId, tokenHash string, ttl int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		 VALUES (?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND))`,
+		userId,
+		familyId,
+		tokenHash,
+		ttl,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, ttl int) (*models.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+	defer tx.Rollback()
+
+	var token models.RefreshToken
+	err = tx.GetContext(
+		ctx,
+		&token,
+		`SELECT id, user_id, family_id, token_hash, expires_at < CURRENT_TIMESTAMP AS expired,
+		        rotated_at, revoked_at
+		 FROM refresh_tokens
+		 WHERE token_hash = ?
+		 FOR UPDATE`,
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+
+	if token.RotatedAt != nil || token.RevokedAt != nil {
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+			 WHERE family_id = ? AND revoked_at IS NULL`,
+			token.FamilyId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+		}
+
+		// The family id is the session id; revoking the session cuts off the
+		// access tokens already issued to it once SyncRevocations runs.
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+			 WHERE id = ? AND revoked_at IS NULL`,
+			token.FamilyId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+		}
+		return nil, messages.ErrRefreshTokenReused
+	}
+
+	if token.Expired {
+		return nil, messages.ErrRefreshTokenExpired
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET rotated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		token.Id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		 VALUES (?, ?, ?, DATE_ADD(CURRENT_TIMESTAMP, INTERVAL ? SECOND))`,
+		token.UserId,
+		token.FamilyId,
+		newTokenHash,
+		ttl,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+
+	return &token, nil
+}
+
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, tokenHash string, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		 WHERE revoked_at IS NULL AND family_id = (
+			SELECT family_id FROM (
+				SELECT family_id FROM refresh_tokens WHERE token_hash = ? AND user_id = ?
+			) AS t
+		 )`,
+		tokenHash,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) RevokeToken(ctx context.Context, jti string, userId int, expiresAt int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		 VALUES (?, ?, FROM_UNIXTIME(?))
+		 ON DUPLICATE KEY UPDATE jti = jti`,
+		jti,
+		userId,
+		expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) GetRevokedTokens(ctx context.Context) ([]models.RevokedToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tokens []models.RevokedToken
+	err := r.db.SelectContext(
+		ctx,
+		&tokens,
+		`SELECT jti, UNIX_TIMESTAMP(expires_at) AS expires_at
+		 FROM revoked_tokens
+		 WHERE expires_at >= CURRENT_TIMESTAMP`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return tokens, nil
+}
+
+func (r *TokenRepository) PruneRevokedTokens(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected, nil
+}
+
+func (r *TokenRepository) GetTokenVersion(ctx context.Context, userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var version int
+	err := r.db.GetContext(ctx, &version, `SELECT token_version FROM users WHERE id = ?`, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, messages.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+	}
+
+	return version, nil
+}
+
+func (r *TokenRepository) BumpTokenVersion(ctx context.Context, userId int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.GetContext(ctx, &version, `SELECT token_version FROM users WHERE id = ? FOR UPDATE`, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, messages.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+	version++
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET token_version = ? WHERE id = ?`, version, userId)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		 WHERE user_id = ? AND revoked_at IS NULL`,
+		userId,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	return version, nil
+}
+
+func (r *TokenRepository) CreateSession(ctx context.Context, sessionId string, userId int, client models.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO sessions (id, user_id, user_agent, ip) VALUES (?, ?, ?, ?)`,
+		sessionId,
+		userId,
+		truncate(client.UserAgent, 512),
+		truncate(client.IP, 45),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrStoringRefreshToken, err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) GetSessions(ctx context.Context, userId int) ([]models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sessions := []models.Session{}
+	err := r.db.SelectContext(
+		ctx,
+		&sessions,
+		`SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_seen_at
+		 FROM sessions s
+		 WHERE s.user_id = ? AND s.revoked_at IS NULL AND EXISTS (
+			SELECT 1 FROM refresh_tokens rt
+			WHERE rt.family_id = s.id AND rt.rotated_at IS NULL AND rt.revoked_at IS NULL
+			  AND rt.expires_at > CURRENT_TIMESTAMP
+		 )
+		 ORDER BY s.last_seen_at DESC, s.created_at DESC`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return sessions, nil
+}
+
+func (r *TokenRepository) RevokeSession(ctx context.Context, sessionId string, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		sessionId,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+	if rowsAffected == 0 {
+		return messages.ErrSessionNotFound
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		 WHERE family_id = ? AND user_id = ? AND revoked_at IS NULL`,
+		sessionId,
+		userId,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrRevokingToken, err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) TouchSession(ctx context.Context, sessionId string, interval int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND revoked_at IS NULL
+		   AND last_seen_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND)`,
+		sessionId,
+		interval,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return nil
+}
+
+// GetRevokedSessions returns sessions whose access tokens may still be in
+// circulation, i.e. revoked less than ttl seconds ago.
+func (r *TokenRepository) GetRevokedSessions(ctx context.Context, ttl int) ([]models.RevokedToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var sessions []models.RevokedToken
+	err := r.db.SelectContext(
+		ctx,
+		&sessions,
+		`SELECT id AS jti, UNIX_TIMESTAMP(revoked_at) + ? AS expires_at
+		 FROM sessions
+		 WHERE revoked_at >= DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND)`,
+		ttl,
+		ttl,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	return sessions, nil
+}
+
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+	for size > 0 && !utf8.RuneStart(value[size]) {
+		size--
+	}
+	return value[:size]
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"time"
+)
+
+type UserRepositoryInterface interface {
+	GetUserById(ctx context.Context, id int) (*models.User, error)
+	GetProfile(ctx context.Context, id int) (*models.PublicUser, error)
+	UpdateProfile(ctx context.Context, id int, req *models.UserUpdateRequest) error
+	UpdateRole(ctx context.Context, id int, role string) error
+	UpdatePassword(ctx context.Context, id int, password string) error
+	MarkVerificationSent(ctx context.Context, id int, interval int) (bool, error)
+	MarkEmailVerified(ctx context.Context, id int) error
+}
+
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserRepository(db *sqlx.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) GetUserById(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := r.db.GetContext(ctx,
+		&user,
+		`SELECT id, username, password, email, role, email_verified_at, display_name, bio, avatar_url,
+		        mfa_secret, mfa_enabled_at, created_at, updated_at
+		 FROM users WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) GetProfile(ctx context.Context, id int) (*models.PublicUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user models.PublicUser
+	err := r.db.GetContext(ctx,
+		&user,
+		`SELECT u.id, u.username, u.display_name, u.bio, u.avatar_url, u.role, u.created_at,
+		        (SELECT COUNT(*) FROM articles a WHERE a.user_id = u.id) AS articles_count,
+		        (SELECT COUNT(*) FROM follows f WHERE f.followee_id = u.id) AS followers_count,
+		        (SELECT COUNT(*) FROM follows f WHERE f.follower_id = u.id) AS following_count
+		 FROM users u WHERE u.id = ?`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, messages.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, id int, req *models.UserUpdateRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users SET
+			display_name = COALESCE(?, display_name),
+			bio = COALESCE(?, bio),
+			avatar_url = COALESCE(?, avatar_url)
+		 WHERE id = ?`,
+		req.DisplayName,
+		req.Bio,
+		req.AvatarUrl,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET role = ? WHERE id = ?`, role, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	if rowsAffected == 0 {
+		exists, err := r.userExists(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return messages.ErrUserNotFound
+		}
+	}
+	return nil
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id int, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET password = ? WHERE id = ?`, password, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) MarkVerificationSent(ctx context.Context, id int, interval int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET verification_sent_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND (verification_sent_at IS NULL
+		       OR verification_sent_at < DATE_SUB(CURRENT_TIMESTAMP, INTERVAL ? SECOND))`,
+		id,
+		interval,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users SET email_verified_at = CURRENT_TIMESTAMP WHERE id = ? AND email_verified_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrUpdatingUser, err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) userExists(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)`, id)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", messages.ErrDatabaseOperation, err)
+	}
+	return exists, nil
+}
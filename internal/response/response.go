@@ -9,4 +9,5 @@ type ErrorResponse struct {
 	Code    int         `json:"code"`
 	Message interface{} `json:"message"`
 	Error   string      `json:"error,omitempty"`
+	Field   string      `json:"field,omitempty"`
 }
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"restapp/config"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"strings"
+	"time"
+)
+
+const exportStaleAfter = 600
+
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+type AccountServiceInterface interface {
+	Export(ctx context.Context, userId int) (*models.DataExport, error)
+	RequestExport(ctx context.Context, userId int) (*models.DataExport, error)
+	ProcessExports(ctx context.Context) error
+	RequestDeletion(ctx context.Context, userId int) (*models.AccountDeletion, error)
+	CancelDeletion(ctx context.Context, userId int) error
+	PurgeAccounts(ctx context.Context) error
+	Wake() <-chan struct{}
+}
+
+type AccountService struct {
+	r    repositories.AccountRepositoryInterface
+	u    repositories.UserRepositoryInterface
+	wake chan struct{}
+	cfg  *config.Config
+}
+
+func NewAccountService(r repositories.AccountRepositoryInterface, u repositories.UserRepositoryInterface, cfg *config.Config) *AccountService {
+	return &AccountService{r: r, u: u, wake: make(chan struct{}, 1), cfg: cfg}
+}
+
+func (s *AccountService) Wake() <-chan struct{} {
+	return s.wake
+}
+
+func (s *AccountService) Export(ctx context.Context, userId int) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	export, err := s.r.GetLatestExport(ctx, userId)
+	if err == nil {
+		if export.Status != models.ExportStatusReady || fileExists(*export.FilePath) {
+			return export, nil
+		}
+	} else if !errors.Is(err, messages.ErrExportNotFound) {
+		return nil, err
+	}
+
+	return s.queueExport(ctx, userId)
+}
+
+// RequestExport starts a fresh export even when a ready archive exists, so
+// changes made since the last export are included.
+func (s *AccountService) RequestExport(ctx context.Context, userId int) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	export, err := s.r.GetLatestExport(ctx, userId)
+	if err == nil {
+		if export.Status == models.ExportStatusPending || export.Status == models.ExportStatusProcessing {
+			return export, nil
+		}
+	} else if !errors.Is(err, messages.ErrExportNotFound) {
+		return nil, err
+	}
+
+	return s.queueExport(ctx, userId)
+}
+
+func (s *AccountService) queueExport(ctx context.Context, userId int) (*models.DataExport, error) {
+	export, err := s.r.CreateExport(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return export, nil
+}
+
+func (s *AccountService) ProcessExports(ctx context.Context) error {
+	paths, err := s.r.PruneExports(ctx)
+	if err != nil {
+		return err
+	}
+	removeFiles(paths)
+
+	for {
+		export, err := s.r.ClaimExport(ctx, exportStaleAfter)
+		if err != nil {
+			if errors.Is(err, messages.ErrExportNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		path, err := s.buildExport(ctx, export)
+		if err != nil {
+			log.Printf("Building export %d failed: %v", export.Id, err)
+			err = s.r.FailExport(ctx, export.Id, err.Error())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = s.r.CompleteExport(ctx, export.Id, path, s.cfg.Account.ExportExpiration)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *AccountService) buildExport(ctx context.Context, export *models.DataExport) (string, error) {
+	user, err := s.u.GetUserById(ctx, export.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	profile, err := s.u.GetProfile(ctx, export.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := s.r.GetExportData(ctx, export.UserId)
+	if err != nil {
+		return "", err
+	}
+	data.ExportedAt = time.Now().UTC().Format(time.RFC3339)
+	data.Profile = models.NewCurrentUser(user, profile.ArticlesCount)
+
+	err = os.MkdirAll(s.cfg.Account.ExportDir, 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	suffix, err := randomHex(8)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.cfg.Account.ExportDir, fmt.Sprintf("export-%d-%s.zip", export.UserId, suffix))
+
+	err = writeExportArchive(path, data)
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (s *AccountService) RequestDeletion(ctx context.Context, userId int) (*models.AccountDeletion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.r.ScheduleDeletion(ctx, userId, s.cfg.Account.DeletionGrace)
+}
+
+func (s *AccountService) CancelDeletion(ctx context.Context, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.r.CancelDeletion(ctx, userId)
+}
+
+func (s *AccountService) PurgeAccounts(ctx context.Context) error {
+	ids, err := s.r.GetDueDeletions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	reassignTo := 0
+	switch s.cfg.Account.ArticlePolicy {
+	case models.ArticlePolicyDelete, "":
+	case models.ArticlePolicyReassign:
+		reassignTo, err = s.r.GetUserIdByUsername(ctx, s.cfg.Account.ReassignTo)
+		if err != nil {
+			return fmt.Errorf("reassign target %q: %w", s.cfg.Account.ReassignTo, err)
+		}
+	default:
+		return fmt.Errorf("unknown article policy %q", s.cfg.Account.ArticlePolicy)
+	}
+
+	for _, id := range ids {
+		if id == reassignTo {
+			log.Printf("Skipping deletion of user %d: it is the article reassignment target", id)
+			continue
+		}
+
+		paths, err := s.r.PurgeAccount(ctx, id, reassignTo)
+		if err != nil {
+			if errors.Is(err, messages.ErrDeletionNotScheduled) {
+				continue
+			}
+			return err
+		}
+		removeFiles(paths)
+	}
+
+	return nil
+}
+
+func writeExportArchive(path string, data *models.ExportData) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := []archiveFile{
+		{"export.json", content},
+		{"README.md", []byte(exportMarkdown(data))},
+	}
+	for _, article := range data.Articles {
+		files = append(files, archiveFile{
+			fmt.Sprintf("articles/%d.md", article.Id),
+			[]byte(fmt.Sprintf("# %s\n\n_Published %s, updated %s_\n\n%s\n", article.Title, article.CreatedAt, article.UpdatedAt, article.Content)),
+		})
+	}
+
+	for _, f := range files {
+		w, err := archive.Create(f.name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(f.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func exportMarkdown(data *models.ExportData) string {
+	var sb strings.Builder
+	profile := data.Profile
+
+	sb.WriteString(fmt.Sprintf("# Data export for %s\n\n", profile.Username))
+	sb.WriteString(fmt.Sprintf("Exported at %s.\n\n", data.ExportedAt))
+
+	sb.WriteString("## Profile\n\n")
+	sb.WriteString(fmt.Sprintf("- Username: %s\n", profile.Username))
+	sb.WriteString(fmt.Sprintf("- Email: %s\n", profile.Email))
+	if profile.DisplayName != nil {
+		sb.WriteString(fmt.Sprintf("- Display name: %s\n", *profile.DisplayName))
+	}
+	if profile.Bio != nil {
+		sb.WriteString(fmt.Sprintf("- Bio: %s\n", *profile.Bio))
+	}
+	if profile.AvatarUrl != nil {
+		sb.WriteString(fmt.Sprintf("- Avatar: %s\n", *profile.AvatarUrl))
+	}
+	sb.WriteString(fmt.Sprintf("- Role: %s\n", profile.Role))
+	sb.WriteString(fmt.Sprintf("- Joined: %s\n\n", profile.JoinedAt))
+
+	sb.WriteString(fmt.Sprintf("## Articles (%d)\n\n", len(data.Articles)))
+	for _, article := range data.Articles {
+		sb.WriteString(fmt.Sprintf("- [%s](articles/%d.md) — %s\n", article.Title, article.Id, article.CreatedAt))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n## Comments (%d)\n\n", len(data.Comments)))
+	for _, comment := range data.Comments {
+		sb.WriteString(fmt.Sprintf("### On \"%s\" — %s\n\n%s\n\n", comment.ArticleTitle, comment.CreatedAt, comment.Content))
+	}
+
+	sb.WriteString(fmt.Sprintf("## Reactions (%d)\n\n", len(data.Reactions)))
+	for _, reaction := range data.Reactions {
+		sb.WriteString(fmt.Sprintf("- %s on \"%s\" — %s\n", reaction.Type, reaction.ArticleTitle, reaction.CreatedAt))
+	}
+
+	return sb.String()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Removing %s failed: %v", path, err)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
+	"restapp/config"
 	"restapp/internal/messages"
 	"restapp/internal/models"
 	"restapp/internal/repositories"
@@ -13,18 +15,24 @@ type ArticleServiceInterface interface {
 	GetAllArticles(ctx context.Context) (*[]models.Article, error)
 	GetById(ctx context.Context, id int) (*models.Article, error)
 	CreateArticle(ctx context.Context, article *models.ArticleRequest, userId int) error
-	UpdateArticle(ctx context.Context, id int, article *models.ArticleRequest) error
-	DeleteArticle(ctx context.Context, id int) error
-	LikeArticle(ctx context.Context, articleId int, userId int) error
-	UnlikeArticle(ctx context.Context, articleId int, userId int) error
+	UpdateArticle(ctx context.Context, id int, article *models.ArticleRequest, userId int, role string) error
+	DeleteArticle(ctx context.Context, id int, userId int, role string) error
+	LikeArticle(ctx context.Context, articleId int, userId int) (int, error)
+	UnlikeArticle(ctx context.Context, articleId int, userId int) (int, error)
+	React(ctx context.Context, articleId int, userId int, reactionType string) (int, error)
+	Unreact(ctx context.Context, articleId int, userId int, reactionType string) (int, error)
+	ReconcileCounters(ctx context.Context) (int64, error)
 }
 
 type ArticleService struct {
-	r repositories.ArticleRepositoryInterface
+	r   repositories.ArticleRepositoryInterface
+	u   repositories.UserRepositoryInterface
+	m   MentionServiceInterface
+	cfg *config.Config
 }
 
-func NewArticleService(r repositories.ArticleRepositoryInterface) *ArticleService {
-	return &ArticleService{r: r}
+func NewArticleService(r repositories.ArticleRepositoryInterface, u repositories.UserRepositoryInterface, m MentionServiceInterface, cfg *config.Config) *ArticleService {
+	return &ArticleService{r: r, u: u, m: m, cfg: cfg}
 }
 
 func (s *ArticleService) GetAllArticles(ctx context.Context) (*[]models.Article, error) {
@@ -36,6 +44,11 @@ func (s *ArticleService) GetAllArticles(ctx context.Context) (*[]models.Article,
 		return nil, fmt.Errorf("%w: %v", messages.ErrGettingArticles, err)
 	}
 
+	err = s.setMentions(ctx, *articles)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingArticles, err)
+	}
+
 	return articles, nil
 }
 
@@ -48,13 +61,24 @@ func (s *ArticleService) GetById(ctx context.Context, id int) (*models.Article,
 		return nil, fmt.Errorf("%w: %v", messages.ErrGettingArticles, err)
 	}
 
-	return article, nil
+	articles := []models.Article{*article}
+	err = s.setMentions(ctx, articles)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingArticles, err)
+	}
+
+	return &articles[0], nil
 }
 
 func (s *ArticleService) CreateArticle(ctx context.Context, article *models.ArticleRequest, userId int) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	err := checkVerified(ctx, s.u, userId)
+	if err != nil {
+		return err
+	}
+
 	articleModel := models.Article{
 		Title:     article.Title,
 		Content:   article.Content,
@@ -62,13 +86,28 @@ func (s *ArticleService) CreateArticle(ctx context.Context, article *models.Arti
 		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	return s.r.StoreArticle(ctx, &articleModel, userId)
+	err = s.r.StoreArticle(ctx, &articleModel, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.m.SyncMentions(ctx, models.MentionSourceArticle, articleModel.Id, userId, articleModel.Content)
+	if err != nil {
+		log.Printf("Syncing mentions for article %d failed: %v", articleModel.Id, err)
+	}
+
+	return nil
 }
 
-func (s *ArticleService) UpdateArticle(ctx context.Context, id int, article *models.ArticleRequest) error {
+func (s *ArticleService) UpdateArticle(ctx context.Context, id int, article *models.ArticleRequest, userId int, role string) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	existing, err := s.checkOwnership(ctx, id, userId, role, models.PermArticleUpdateAny)
+	if err != nil {
+		return err
+	}
+
 	articleModel := models.Article{
 		Id:        id,
 		Title:     article.Title,
@@ -76,26 +115,95 @@ func (s *ArticleService) UpdateArticle(ctx context.Context, id int, article *mod
 		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	return s.r.UpdateArticle(ctx, id, &articleModel)
+	err = s.r.UpdateArticle(ctx, id, &articleModel)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.m.SyncMentions(ctx, models.MentionSourceArticle, id, existing.UserId, articleModel.Content)
+	if err != nil {
+		log.Printf("Syncing mentions for article %d failed: %v", id, err)
+	}
+
+	return nil
 }
 
-func (s *ArticleService) DeleteArticle(ctx context.Context, id int) error {
+func (s *ArticleService) DeleteArticle(ctx context.Context, id int, userId int, role string) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	_, err := s.checkOwnership(ctx, id, userId, role, models.PermArticleDeleteAny)
+	if err != nil {
+		return err
+	}
+
 	return s.r.DeleteArticle(ctx, id)
 }
 
-func (s *ArticleService) LikeArticle(ctx context.Context, articleId int, userId int) error {
+func (s *ArticleService) LikeArticle(ctx context.Context, articleId int, userId int) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	return s.r.LikeArticle(ctx, articleId, userId)
+	return s.r.React(ctx, articleId, userId, models.ReactionLike)
 }
 
-func (s *ArticleService) UnlikeArticle(ctx context.Context, articleId int, userId int) error {
+func (s *ArticleService) UnlikeArticle(ctx context.Context, articleId int, userId int) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	return s.r.UnlikeArticle(ctx, articleId, userId)
+	return s.r.Unreact(ctx, articleId, userId, models.ReactionLike)
+}
+
+func (s *ArticleService) React(ctx context.Context, articleId int, userId int, reactionType string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, ok := s.cfg.Reactions.Types[reactionType]; !ok {
+		return 0, messages.ErrInvalidReaction
+	}
+
+	return s.r.React(ctx, articleId, userId, reactionType)
+}
+
+func (s *ArticleService) Unreact(ctx context.Context, articleId int, userId int, reactionType string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, ok := s.cfg.Reactions.Types[reactionType]; !ok {
+		return 0, messages.ErrInvalidReaction
+	}
+
+	return s.r.Unreact(ctx, articleId, userId, reactionType)
+}
+
+func (s *ArticleService) ReconcileCounters(ctx context.Context) (int64, error) {
+	return s.r.ReconcileCounters(ctx)
+}
+
+func (s *ArticleService) checkOwnership(ctx context.Context, id int, userId int, role string, anyPermission string) (*models.Article, error) {
+	article, err := s.r.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if article.UserId != userId && !models.HasPermission(role, anyPermission) {
+		return nil, messages.ErrForbidden
+	}
+	return article, nil
+}
+
+func (s *ArticleService) setMentions(ctx context.Context, articles []models.Article) error {
+	ids := make([]int, len(articles))
+	for i, article := range articles {
+		ids[i] = article.Id
+	}
+
+	mentions, err := s.m.GetMentions(ctx, models.MentionSourceArticle, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range articles {
+		articles[i].Mentions = mentions[articles[i].Id]
+	}
+	return nil
 }
@@ -2,12 +2,23 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"restapp/config"
+	"restapp/internal/keyring"
+	"restapp/internal/mailer"
 	"restapp/internal/messages"
 	"restapp/internal/models"
 	"restapp/internal/repositories"
+	"restapp/internal/totp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,22 +26,55 @@ import (
 )
 
 type AuthServiceInterface interface {
-	Register(ctx context.Context, req *models.RegisterRequest) (*models.UserResponse, error)
-	Login(ctx context.Context, req *models.LoginRequest) (string, error)
+	Register(ctx context.Context, req *models.RegisterRequest, client models.ClientInfo) (*models.UserResponse, error)
+	Login(ctx context.Context, req *models.LoginRequest, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error)
+	LoginUser(ctx context.Context, userId int, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error)
+	VerifyMfa(ctx context.Context, mfaToken string, code string, client models.ClientInfo) (*models.TokenPair, error)
+	EnrollMfa(ctx context.Context, userId int) (*models.MfaEnrollment, error)
+	ConfirmMfa(ctx context.Context, userId int, code string) (*models.RecoveryCodes, error)
+	DisableMfa(ctx context.Context, userId int, code string) error
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, claims *models.Claims, req *models.LogoutRequest) error
+	SyncRevocations(ctx context.Context) error
+	UpdateRole(ctx context.Context, adminId int, userId int, role string) (*models.PublicUser, error)
+	ChangePassword(ctx context.Context, userId int, sessionId string, req *models.ChangePasswordRequest) (*models.TokenPair, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userId int) error
+	UnlockUser(ctx context.Context, userId int) error
+	CreatePersonalToken(ctx context.Context, userId int, req *models.PersonalTokenRequest) (*models.CreatedPersonalToken, error)
+	GetPersonalTokens(ctx context.Context, userId int) ([]models.PersonalToken, error)
+	RevokePersonalToken(ctx context.Context, userId int, tokenId int) error
+	GetSessions(ctx context.Context, userId int, currentSessionId string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userId int, sessionId string) error
+	TouchSession(ctx context.Context, claims *models.Claims) error
 	ValidateToken(tokenString string) (*models.Claims, error)
 	FormatToken(tokenString string) string
+	JWKS() *keyring.JWKS
 }
 
 type AuthService struct {
-	r   repositories.AuthRepositoryInterface
-	cfg *config.Config
+	r     repositories.AuthRepositoryInterface
+	u     repositories.UserRepositoryInterface
+	t     repositories.TokenRepositoryInterface
+	p     repositories.PasswordRepositoryInterface
+	a     repositories.LoginAttemptRepositoryInterface
+	f     repositories.MfaRepositoryInterface
+	k     repositories.PersonalTokenRepositoryInterface
+	m     mailer.Mailer
+	keys  *keyring.Keyring
+	cache *revocationCache
+	cfg   *config.Config
 }
 
-func NewAuthService(r repositories.AuthRepositoryInterface, cfg *config.Config) *AuthService {
-	return &AuthService{r: r, cfg: cfg}
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+func NewAuthService(r repositories.AuthRepositoryInterface, u repositories.UserRepositoryInterface, t repositories.TokenRepositoryInterface, p repositories.PasswordRepositoryInterface, a repositories.LoginAttemptRepositoryInterface, f repositories.MfaRepositoryInterface, k repositories.PersonalTokenRepositoryInterface, m mailer.Mailer, keys *keyring.Keyring, cfg *config.Config) *AuthService {
+	return &AuthService{r: r, u: u, t: t, p: p, a: a, f: f, k: k, m: m, keys: keys, cache: newRevocationCache(), cfg: cfg}
 }
 
-func (s *AuthService) Register(ctx context.Context, user *models.RegisterRequest) (*models.UserResponse, error) {
+func (s *AuthService) Register(ctx context.Context, user *models.RegisterRequest, client models.ClientInfo) (*models.UserResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -43,60 +87,861 @@ func (s *AuthService) Register(ctx context.Context, user *models.RegisterRequest
 		Username:  user.Username,
 		Password:  string(hashedPassword),
 		Email:     user.Email,
-		Role:      "user",
+		Role:      models.DefaultRole,
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
 		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
 	registeredUser, err := s.r.Register(ctx, &userModel)
 	if err != nil {
+		if errors.Is(err, messages.ErrEmailAlreadyExists) || errors.Is(err, messages.ErrUsernameAlreadyExists) || errors.Is(err, messages.ErrUsernameReserved) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("%w: %v", messages.ErrCreatingUser, err)
 	}
 
-	token, err := s.generateToken(registeredUser.Id)
+	tokens, err := s.generateTokenPair(ctx, registeredUser.Id, client)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
 	}
 
+	err = s.sendVerification(ctx, registeredUser)
+	if err != nil {
+		log.Printf("Sending verification to user %d failed: %v", registeredUser.Id, err)
+	}
+
 	return &models.UserResponse{
-		User:  registeredUser,
-		Token: token,
+		User:         models.NewCurrentUser(registeredUser, 0),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, user *models.LoginRequest) (string, error) {
+func (s *AuthService) Login(ctx context.Context, user *models.LoginRequest, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	email := strings.ToLower(user.Email)
+
+	err := s.checkThrottle(ctx, models.LoginScopeAccount, email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.checkThrottle(ctx, models.LoginScopeIP, client.IP)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	userModel, err := s.r.GetUserByEmail(ctx, user.Email)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+		if !errors.Is(err, messages.ErrUserNotFound) {
+			return nil, nil, fmt.Errorf("%w: %v", messages.ErrGettingUser, err)
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(user.Password))
+		return nil, nil, s.recordFailure(ctx, email, client.IP)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(userModel.Password), []byte(user.Password))
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", messages.ErrComparingPasswords, err)
+		return nil, nil, s.recordFailure(ctx, email, client.IP)
+	}
+
+	err = s.a.Reset(ctx, models.LoginScopeAccount, email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.completeLogin(ctx, userModel, client)
+}
+
+func (s *AuthService) LoginUser(ctx context.Context, userId int, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.completeLogin(ctx, user, client)
+}
+
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error) {
+	if user.MfaEnabledAt != nil {
+		challenge, err := s.generateMfaChallenge(user.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, challenge, nil
+	}
+
+	tokens, err := s.generateTokenPair(ctx, user.Id, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, nil, nil
+}
+
+func (s *AuthService) VerifyMfa(ctx context.Context, mfaToken string, code string, client models.ClientInfo) (*models.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	claims, err := s.parseToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAudience(claims, models.AudienceMfa) {
+		return nil, messages.ErrInvalidToken
+	}
+
+	user, err := s.u.GetUserById(ctx, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user.MfaEnabledAt == nil {
+		return nil, messages.ErrMfaNotEnabled
+	}
+
+	err = s.verifyMfaCode(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generateTokenPair(ctx, user.Id, client)
+}
+
+func (s *AuthService) EnrollMfa(ctx context.Context, userId int) (*models.MfaEnrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.MfaEnabledAt != nil {
+		return nil, messages.ErrMfaAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
+	err = s.f.SetSecret(ctx, userId, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MfaEnrollment{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(s.cfg.Auth.MfaIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *AuthService) ConfirmMfa(ctx context.Context, userId int, code string) (*models.RecoveryCodes, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.MfaEnabledAt != nil {
+		return nil, messages.ErrMfaAlreadyEnabled
+	}
+	if user.MfaSecret == nil {
+		return nil, messages.ErrMfaNotEnrolled
+	}
+
+	err = s.verifyMfaCode(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 10)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+
+	err = s.f.Enable(ctx, userId, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (s *AuthService) DisableMfa(ctx context.Context, userId int, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.MfaEnabledAt == nil {
+		return messages.ErrMfaNotEnabled
+	}
+	if models.RequiresMfa(user.Role) {
+		return messages.ErrMfaRequired
+	}
+
+	err = s.verifyMfaCode(ctx, user, code)
+	if err != nil {
+		return err
+	}
+
+	return s.f.Disable(ctx, userId)
+}
+
+func (s *AuthService) verifyMfaCode(ctx context.Context, user *models.User, code string) error {
+	key := strconv.Itoa(user.Id)
+
+	err := s.checkThrottle(ctx, models.LoginScopeMfa, key)
+	if err != nil {
+		return err
+	}
+
+	valid, err := s.checkMfaCode(ctx, user, code)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		err = s.a.RecordFailure(ctx, models.LoginScopeMfa, key, s.cfg.Auth.LoginMaxAttempts, s.cfg.Auth.LoginWindow, s.cfg.Auth.LoginLockout)
+		if err != nil {
+			return err
+		}
+		return messages.ErrInvalidMfaCode
+	}
+
+	return s.a.Reset(ctx, models.LoginScopeMfa, key)
+}
+
+func (s *AuthService) checkMfaCode(ctx context.Context, user *models.User, code string) (bool, error) {
+	if user.MfaSecret == nil {
+		return false, nil
+	}
+
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(*user.MfaSecret, code, time.Now(), 1)
+		if !ok {
+			return false, nil
+		}
+		return s.f.UseStep(ctx, user.Id, step)
+	}
+
+	if user.MfaEnabledAt == nil {
+		return false, nil
+	}
+	return s.f.UseRecoveryCode(ctx, user.Id, hashToken(code))
+}
+
+func (s *AuthService) generateMfaChallenge(userId int) (*models.MfaChallenge, error) {
+	expiresIn := s.cfg.Auth.MfaChallengeExpiration
+
+	claims := &models.Claims{
+		UserId: userId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{models.AudienceMfa},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiresIn) * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token, err := s.signToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MfaChallenge{
+		MfaRequired: true,
+		MfaToken:    token,
+		ExpiresIn:   expiresIn,
+	}, nil
+}
+
+func (s *AuthService) UnlockUser(ctx context.Context, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return s.a.Reset(ctx, models.LoginScopeAccount, strings.ToLower(user.Email))
+}
+
+func (s *AuthService) CreatePersonalToken(ctx context.Context, userId int, req *models.PersonalTokenRequest) (*models.CreatedPersonalToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+	tokenString := models.PersonalTokenPrefix + secret
+
+	token := models.PersonalToken{
+		UserId:      userId,
+		Name:        req.Name,
+		TokenPrefix: tokenString[:len(models.PersonalTokenPrefix)+6],
+		Scopes:      uniqueScopes(req.Scopes),
+	}
+
+	err = s.k.CreatePersonalToken(ctx, &token, hashToken(tokenString), req.ExpiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Split(token.ScopeList, ",")
+	return &models.CreatedPersonalToken{PersonalToken: token, Token: tokenString}, nil
+}
+
+func (s *AuthService) GetPersonalTokens(ctx context.Context, userId int) ([]models.PersonalToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.k.GetPersonalTokens(ctx, userId)
+}
+
+func (s *AuthService) RevokePersonalToken(ctx context.Context, userId int, tokenId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.k.RevokePersonalToken(ctx, tokenId, userId)
+}
+
+func (s *AuthService) validatePersonalToken(tokenString string) (*models.Claims, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := s.k.GetActivePersonalToken(ctx, hashToken(tokenString))
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.tokenVersion(ctx, token.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if token.Version != version {
+		return nil, messages.ErrTokenRevoked
+	}
+
+	err = s.k.TouchPersonalToken(ctx, token.Id)
+	if err != nil {
+		log.Printf("Updating last use of token %d failed: %v", token.Id, err)
+	}
+
+	return &models.Claims{UserId: token.UserId, Role: token.Role, Scopes: token.Scopes}, nil
+}
+
+func (s *AuthService) checkThrottle(ctx context.Context, scope, key string) error {
+	throttle, err := s.a.GetThrottle(ctx, scope, key)
+	if err != nil {
+		return err
+	}
+
+	if throttle.LockedFor > 0 {
+		return messages.ErrTooManyAttempts
+	}
+
+	if throttle.Failures > 0 && throttle.SinceFailure != nil && *throttle.SinceFailure < s.loginBackoff(throttle.Failures) {
+		return messages.ErrTooManyAttempts
+	}
+
+	return nil
+}
+
+func (s *AuthService) recordFailure(ctx context.Context, email, ip string) error {
+	err := s.a.RecordFailure(ctx, models.LoginScopeAccount, email, s.cfg.Auth.LoginMaxAttempts, s.cfg.Auth.LoginWindow, s.cfg.Auth.LoginLockout)
+	if err != nil {
+		return err
+	}
+
+	err = s.a.RecordFailure(ctx, models.LoginScopeIP, ip, s.cfg.Auth.LoginIPMaxAttempts, s.cfg.Auth.LoginWindow, s.cfg.Auth.LoginLockout)
+	if err != nil {
+		return err
+	}
+
+	return messages.ErrInvalidCredentials
+}
+
+func (s *AuthService) loginBackoff(failures int) int {
+	backoff := s.cfg.Auth.LoginBackoffBase
+	for i := 1; i < failures && backoff < s.cfg.Auth.LoginBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > s.cfg.Auth.LoginBackoffMax {
+		backoff = s.cfg.Auth.LoginBackoffMax
+	}
+	return backoff
+}
+
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	newRefreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
+	ttl, err := strconv.Atoi(s.cfg.JWT.RefreshExpiration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrConvertingExpTime, err)
+	}
+
+	rotated, err := s.t.RotateRefreshToken(ctx, hashToken(refreshToken), hashToken(newRefreshToken), ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accessTokenPair(ctx, rotated.UserId, rotated.FamilyId, newRefreshToken)
+}
+
+func (s *AuthService) Logout(ctx context.Context, claims *models.Claims, req *models.LogoutRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if req.All {
+		version, err := s.t.BumpTokenVersion(ctx, claims.UserId)
+		if err != nil {
+			return err
+		}
+		s.cache.setVersion(claims.UserId, version)
+		return nil
+	}
+
+	if req.RefreshToken != "" {
+		err := s.t.RevokeRefreshToken(ctx, hashToken(req.RefreshToken), claims.UserId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if claims.SessionId != "" {
+		err := s.RevokeSession(ctx, claims.UserId, claims.SessionId)
+		if err != nil && !errors.Is(err, messages.ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	err := s.t.RevokeToken(ctx, claims.ID, claims.UserId, claims.ExpiresAt.Unix())
+	if err != nil {
+		return err
+	}
+	s.cache.revoke(claims.ID, claims.ExpiresAt.Unix())
+
+	return nil
+}
+
+func (s *AuthService) SyncRevocations(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.t.PruneRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := s.t.GetRevokedTokens(ctx)
+	if err != nil {
+		return err
 	}
 
-	return s.generateToken(userModel.Id)
+	expiresIn, err := strconv.Atoi(s.cfg.JWT.Expiration)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrConvertingExpTime, err)
+	}
+
+	sessions, err := s.t.GetRevokedSessions(ctx, expiresIn)
+	if err != nil {
+		return err
+	}
+
+	s.cache.reset(tokens, sessions)
+	return nil
+}
+
+func (s *AuthService) GetSessions(ctx context.Context, userId int, currentSessionId string) ([]models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sessions, err := s.t.GetSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == currentSessionId
+	}
+
+	return sessions, nil
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, userId int, sessionId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	expiresIn, err := strconv.Atoi(s.cfg.JWT.Expiration)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrConvertingExpTime, err)
+	}
+
+	err = s.t.RevokeSession(ctx, sessionId, userId)
+	if err != nil {
+		return err
+	}
+	s.cache.revokeSession(sessionId, time.Now().Add(time.Duration(expiresIn)*time.Second).Unix())
+
+	return nil
+}
+
+func (s *AuthService) TouchSession(ctx context.Context, claims *models.Claims) error {
+	if claims.SessionId == "" {
+		return nil
+	}
+
+	interval := time.Duration(s.cfg.Auth.SessionTouchInterval) * time.Second
+	if !s.cache.touch(claims.SessionId, time.Now(), interval) {
+		return nil
+	}
+
+	return s.t.TouchSession(ctx, claims.SessionId, s.cfg.Auth.SessionTouchInterval)
+}
+
+func (s *AuthService) UpdateRole(ctx context.Context, adminId int, userId int, role string) (*models.PublicUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if adminId == userId {
+		return nil, messages.ErrForbidden
+	}
+
+	if !models.IsValidRole(role) {
+		return nil, messages.ErrInvalidRole
+	}
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if models.RequiresMfa(role) && user.MfaEnabledAt == nil {
+		return nil, messages.ErrMfaRequired
+	}
+
+	err = s.u.UpdateRole(ctx, userId, role)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.t.BumpTokenVersion(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.setVersion(userId, version)
+
+	return s.u.GetProfile(ctx, userId)
+}
+
+func (s *AuthService) ChangePassword(ctx context.Context, userId int, sessionId string, req *models.ChangePasswordRequest) (*models.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrWrongPassword, err)
+	}
+
+	err = s.setPassword(ctx, userId, req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionId == "" {
+		return s.generateTokenPair(ctx, userId, models.ClientInfo{})
+	}
+	return s.issueTokenPair(ctx, userId, sessionId)
 }
 
-func (s *AuthService) generateToken(userId int) (string, error) {
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.r.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
+	err = s.p.CreateResetToken(ctx, user.Id, hashToken(token), s.cfg.Auth.ResetExpiration)
+	if err != nil {
+		return err
+	}
+
+	err = s.m.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to reset your password. It expires in %d minutes.\n\n%s/reset-password?token=%s\n\nIf you did not request a reset, ignore this email.",
+			user.Username, s.cfg.Auth.ResetExpiration/60, s.cfg.App.URL, token,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrSendingMail, err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) ResetPassword(ctx context.Context, token string, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	userId, err := s.p.ConsumeResetToken(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	return s.setPassword(ctx, userId, password)
+}
+
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return messages.ErrInvalidVerification
+	}
+
+	userId, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return messages.ErrInvalidVerification
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return messages.ErrInvalidVerification
+	}
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, messages.ErrUserNotFound) {
+			return messages.ErrInvalidVerification
+		}
+		return err
+	}
+
+	expected := s.signVerification(user.Id, user.Email, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return messages.ErrInvalidVerification
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.u.MarkEmailVerified(ctx, user.Id)
+}
+
+func (s *AuthService) ResendVerification(ctx context.Context, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return messages.ErrAlreadyVerified
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, user *models.User) error {
+	sent, err := s.u.MarkVerificationSent(ctx, user.Id, s.cfg.Auth.VerificationResend)
+	if err != nil {
+		return err
+	}
+	if !sent {
+		return messages.ErrVerificationThrottled
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.Auth.VerificationExpiration) * time.Second).Unix()
+	token := fmt.Sprintf("%d.%d.%s", user.Id, expiresAt, s.signVerification(user.Id, user.Email, expiresAt))
+
+	err = s.m.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below.\n\n%s/auth/verify?token=%s\n",
+			user.Username, s.cfg.App.URL, token,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrSendingMail, err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) signVerification(userId int, email string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.JWT.Secret))
+	mac.Write([]byte(fmt.Sprintf("verify:%d:%s:%d", userId, email, expiresAt)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *AuthService) setPassword(ctx context.Context, userId int, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("%w: %v", messages.ErrHashingPassword, err)
+	}
+
+	err = s.u.UpdatePassword(ctx, userId, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+
+	version, err := s.t.BumpTokenVersion(ctx, userId)
+	if err != nil {
+		return err
+	}
+	s.cache.setVersion(userId, version)
+
+	return nil
+}
+
+// generateTokenPair starts a new session; its id doubles as the refresh token
+// family, so revoking the session revokes every refresh token issued for it.
+func (s *AuthService) generateTokenPair(ctx context.Context, userId int, client models.ClientInfo) (*models.TokenPair, error) {
+	sessionId, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
+	err = s.t.CreateSession(ctx, sessionId, userId, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokenPair(ctx, userId, sessionId)
+}
+
+func (s *AuthService) issueTokenPair(ctx context.Context, userId int, sessionId string) (*models.TokenPair, error) {
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
+	ttl, err := strconv.Atoi(s.cfg.JWT.RefreshExpiration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrConvertingExpTime, err)
+	}
+
+	err = s.t.CreateRefreshToken(ctx, userId, sessionId, hashToken(refreshToken), ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accessTokenPair(ctx, userId, sessionId, refreshToken)
+}
+
+func (s *AuthService) accessTokenPair(ctx context.Context, userId int, sessionId string, refreshToken string) (*models.TokenPair, error) {
+	user, err := s.u.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.tokenVersion(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.generateToken(user.Id, user.Role, version, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn, err := strconv.Atoi(s.cfg.JWT.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrConvertingExpTime, err)
+	}
+
+	return &models.TokenPair{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    expiresIn,
+	}, nil
+}
+
+func (s *AuthService) generateToken(userId int, role string, version int, sessionId string) (string, error) {
 	expirationTime, err := strconv.Atoi(s.cfg.JWT.Expiration)
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", messages.ErrConvertingExpTime, err)
 	}
 
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
 	claims := &models.Claims{
-		UserId: userId,
+		UserId:       userId,
+		Role:         role,
+		TokenVersion: version,
+		SessionId:    sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expirationTime) * time.Second)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.cfg.JWT.Secret))
+	return s.signToken(claims)
+}
+
+func (s *AuthService) signToken(claims *models.Claims) (string, error) {
+	key, err := s.keys.Signing(time.Now())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", messages.ErrSigningToken, err)
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.Kid != "" {
+		token.Header["kid"] = key.Kid
+	}
+
+	tokenString, err := token.SignedString(key.SignKey())
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", messages.ErrSigningToken, err)
 	}
@@ -104,14 +949,56 @@ func (s *AuthService) generateToken(userId int) (string, error) {
 	return tokenString, nil
 }
 
+func (s *AuthService) JWKS() *keyring.JWKS {
+	return s.keys.JWKS(time.Now())
+}
+
 func (s *AuthService) ValidateToken(tokenString string) (*models.Claims, error) {
+	if strings.HasPrefix(tokenString, models.PersonalTokenPrefix) {
+		return s.validatePersonalToken(tokenString)
+	}
+
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(claims.Audience) > 0 {
+		return nil, messages.ErrInvalidToken
+	}
+
+	if claims.ID != "" && s.cache.isRevoked(claims.ID) {
+		return nil, messages.ErrTokenRevoked
+	}
+
+	if claims.SessionId != "" && s.cache.isSessionRevoked(claims.SessionId) {
+		return nil, messages.ErrSessionRevoked
+	}
+
+	version, err := s.tokenVersion(context.Background(), claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenVersion != version {
+		return nil, messages.ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+func (s *AuthService) parseToken(tokenString string) (*models.Claims, error) {
 	claims := &models.Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.keys.Verification(kid, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, messages.ErrInvalidSigningMethod
 		}
-		return []byte(s.cfg.JWT.Secret), nil
+		return key.VerifyKey(), nil
 	})
 
 	if err != nil {
@@ -125,6 +1012,20 @@ func (s *AuthService) ValidateToken(tokenString string) (*models.Claims, error)
 	return claims, nil
 }
 
+func (s *AuthService) tokenVersion(ctx context.Context, userId int) (int, error) {
+	if version, ok := s.cache.version(userId); ok {
+		return version, nil
+	}
+
+	version, err := s.t.GetTokenVersion(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	s.cache.setVersion(userId, version)
+	return version, nil
+}
+
 func (s *AuthService) FormatToken(tokenString string) string {
 	const prefix = "Bearer "
 	if len(tokenString) > len(prefix) && tokenString[:len(prefix)] == prefix {
@@ -132,3 +1033,48 @@ func (s *AuthService) FormatToken(tokenString string) string {
 	}
 	return ""
 }
+
+func hasAudience(claims *models.Claims, audience string) bool {
+	for _, a := range claims.Audience {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueScopes(scopes []string) []string {
+	result := []string{}
+	for _, scope := range models.Scopes {
+		for _, s := range scopes {
+			if s == scope {
+				result = append(result, scope)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"restapp/config"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"testing"
+)
+
+// fakeTokenVersions keeps a single user's token version.
+type fakeTokenVersions struct {
+	repositories.TokenRepositoryInterface
+	version int
+}
+
+func (r *fakeTokenVersions) GetTokenVersion(ctx context.Context, userId int) (int, error) {
+	return r.version, nil
+}
+
+func (r *fakeTokenVersions) BumpTokenVersion(ctx context.Context, userId int) (int, error) {
+	r.version++
+	return r.version, nil
+}
+
+type fakeResetTokens struct {
+	repositories.PasswordRepositoryInterface
+	userId int
+}
+
+func (r *fakeResetTokens) ConsumeResetToken(ctx context.Context, tokenHash string) (int, error) {
+	return r.userId, nil
+}
+
+type fakePasswords struct {
+	repositories.UserRepositoryInterface
+}
+
+func (r *fakePasswords) UpdatePassword(ctx context.Context, id int, password string) error {
+	return nil
+}
+
+// fakePersonalTokens returns one token issued at the given token version.
+type fakePersonalTokens struct {
+	repositories.PersonalTokenRepositoryInterface
+	token models.PersonalToken
+}
+
+func (r *fakePersonalTokens) GetActivePersonalToken(ctx context.Context, tokenHash string) (*models.PersonalToken, error) {
+	if tokenHash != hashToken("pat_secret") {
+		return nil, messages.ErrInvalidToken
+	}
+	token := r.token
+	return &token, nil
+}
+
+func (r *fakePersonalTokens) TouchPersonalToken(ctx context.Context, id int) error {
+	return nil
+}
+
+func TestPersonalTokenRejectedAfterPasswordReset(t *testing.T) {
+	const userId = 7
+
+	versions := &fakeTokenVersions{version: 2}
+	tokens := &fakePersonalTokens{token: models.PersonalToken{
+		Id:      1,
+		UserId:  userId,
+		Role:    models.RoleAuthor,
+		Scopes:  []string{models.ScopeArticlesRead},
+		Version: versions.version,
+	}}
+	s := NewAuthService(nil, &fakePasswords{}, versions, &fakeResetTokens{userId: userId}, nil, nil, tokens, nil, nil, &config.Config{})
+
+	claims, err := s.ValidateToken("pat_secret")
+	if err != nil {
+		t.Fatalf("ValidateToken before reset: %v", err)
+	}
+	if claims.UserId != userId {
+		t.Fatalf("claims.UserId = %d, want %d", claims.UserId, userId)
+	}
+
+	err = s.ResetPassword(context.Background(), "reset-token", "new-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.ValidateToken("pat_secret")
+	if !errors.Is(err, messages.ErrTokenRevoked) {
+		t.Fatalf("ValidateToken after reset: err = %v, want %v", err, messages.ErrTokenRevoked)
+	}
+}
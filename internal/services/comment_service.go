@@ -2,7 +2,12 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"restapp/config"
 	"restapp/internal/messages"
 	"restapp/internal/models"
 	"restapp/internal/repositories"
@@ -11,16 +16,34 @@ import (
 
 type CommentServiceInterface interface {
 	CreateComment(ctx context.Context, req *models.CommentRequest, articleId, userId int) (*models.Comment, error)
-	GetAllComments(ctx context.Context, articleId int) (*[]models.Comment, error)
+	GetComment(ctx context.Context, articleId, commentId, viewerId int) (*models.Comment, error)
+	GetThreads(ctx context.Context, articleId int, query models.CommentQuery) (*models.CommentList, error)
+	GetReplies(ctx context.Context, articleId, commentId, userId int, flat bool) (*[]models.Comment, error)
+	UpdateComment(ctx context.Context, req *models.CommentRequest, articleId, commentId, userId int) (*models.Comment, error)
+	DeleteComment(ctx context.Context, articleId, commentId, userId int) error
+	GetCommentHistory(ctx context.Context, articleId, commentId, userId int) (*[]models.CommentEdit, error)
+	Vote(ctx context.Context, articleId, commentId, userId, value int) (*models.Comment, error)
+	GetSettings(ctx context.Context, articleId int) (*models.CommentSettings, error)
+	UpdateSettings(ctx context.Context, req *models.CommentSettingsRequest, articleId, userId int) (*models.CommentSettings, error)
+	GetPendingComments(ctx context.Context, articleId, userId int) (*[]models.Comment, error)
+	ModerateComment(ctx context.Context, articleId, commentId, userId int, approve bool) error
 }
 
 type CommentService struct {
 	CommentRepository repositories.CommentRepositoryInterface
+	UserRepository    repositories.UserRepositoryInterface
+	FollowRepository  repositories.FollowRepositoryInterface
+	MentionService    MentionServiceInterface
+	cfg               *config.Config
 }
 
-func NewCommentService(CommentRepository repositories.CommentRepositoryInterface) *CommentService {
+func NewCommentService(CommentRepository repositories.CommentRepositoryInterface, UserRepository repositories.UserRepositoryInterface, FollowRepository repositories.FollowRepositoryInterface, MentionService MentionServiceInterface, cfg *config.Config) *CommentService {
 	return &CommentService{
 		CommentRepository: CommentRepository,
+		UserRepository:    UserRepository,
+		FollowRepository:  FollowRepository,
+		MentionService:    MentionService,
+		cfg:               cfg,
 	}
 }
 
@@ -28,26 +51,603 @@ func (s *CommentService) CreateComment(ctx context.Context, comment *models.Comm
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	err := checkVerified(ctx, s.UserRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
 	commentModel := models.Comment{
 		ArticleId: articleId,
 		UserId:    userId,
+		ParentId:  comment.ParentId,
 		Content:   comment.Content,
+		Status:    models.CommentStatusApproved,
 		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
 		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	err := s.CommentRepository.CreateComment(ctx, &commentModel, articleId)
+	settings, err := s.CommentRepository.GetSettings(ctx, articleId)
 	if err != nil {
+		return nil, err
+	}
+
+	manager, err := s.canManage(ctx, settings, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !manager {
+		err = s.checkAudience(ctx, settings, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		if settings.RequireApproval {
+			commentModel.Status = models.CommentStatusPending
+		}
+	}
+
+	if comment.ParentId != nil {
+		parent, err := s.CommentRepository.GetCommentById(ctx, *comment.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		if parent.DeletedAt != nil || parent.Status != models.CommentStatusApproved {
+			return nil, messages.ErrCommentNotFound
+		}
+		if parent.ArticleId != articleId {
+			return nil, messages.ErrParentMismatch
+		}
+		if parent.Depth+1 > s.cfg.Comments.MaxDepth {
+			return nil, messages.ErrCommentTooDeep
+		}
+
+		commentModel.Depth = parent.Depth + 1
+		commentModel.Path = parent.Path
+	}
+
+	err = s.CommentRepository.CreateComment(ctx, &commentModel, articleId)
+	if err != nil {
+		if errors.Is(err, messages.ErrArticleNotFound) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("%w: %v", messages.ErrCreatingComment, err)
 	}
+
+	// Pending comments notify nobody until they are approved.
+	if commentModel.Status != models.CommentStatusApproved {
+		return &commentModel, nil
+	}
+
+	commentModel.Mentions, err = s.MentionService.SyncMentions(ctx, models.MentionSourceComment, commentModel.Id, userId, commentModel.Content)
+	if err != nil {
+		log.Printf("Syncing mentions for comment %d failed: %v", commentModel.Id, err)
+	}
 	return &commentModel, nil
 }
 
-func (s *CommentService) GetAllComments(ctx context.Context, articleId int) (*[]models.Comment, error) {
-	comments, err := s.CommentRepository.GetAllComments(ctx, articleId)
+func (s *CommentService) GetComment(ctx context.Context, articleId, commentId, viewerId int) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	comment, err := s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ArticleId != articleId {
+		return nil, messages.ErrCommentNotFound
+	}
+	if comment.Status != models.CommentStatusApproved && comment.UserId != viewerId {
+		return nil, messages.ErrCommentNotFound
+	}
+
+	comments := []models.Comment{*comment}
+	err = s.setVotes(ctx, comments, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.setMentions(ctx, comments)
+	if err != nil {
+		return nil, err
+	}
+	return &comments[0], nil
+}
+
+func (s *CommentService) GetThreads(ctx context.Context, articleId int, query models.CommentQuery) (*models.CommentList, error) {
+	switch query.Sort {
+	case "":
+		query.Sort = models.CommentSortOldest
+	case models.CommentSortOldest, models.CommentSortNewest, models.CommentSortTop:
+	default:
+		return nil, messages.ErrInvalidSort
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = s.cfg.Comments.PageSize
+	}
+	if query.Limit > s.cfg.Comments.MaxPageSize {
+		query.Limit = s.cfg.Comments.MaxPageSize
+	}
+	if query.MaxReplies <= 0 || query.MaxReplies > s.cfg.Comments.MaxReplies {
+		query.MaxReplies = s.cfg.Comments.MaxReplies
+	}
+
+	var after *models.CommentCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCommentCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = cursor
+	}
+
+	// An unknown article is reported as such rather than as an empty thread.
+	_, err := s.CommentRepository.GetSettings(ctx, articleId)
+	if err != nil {
+		return nil, err
+	}
+
+	threads, err := s.CommentRepository.GetThreads(ctx, articleId, query.Sort, after, query.Limit+1)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
 	}
 
+	var list models.CommentList
+	if len(threads) > query.Limit {
+		threads = threads[:query.Limit]
+		list.NextCursor = encodeCommentCursor(query.Sort, threads[len(threads)-1])
+	}
+
+	comments, err := s.loadReplies(ctx, articleId, threads, 0, query.MaxReplies, query.ViewerId, query.Flat)
+	if err != nil {
+		return nil, err
+	}
+
+	list.Comments = *comments
+	return &list, nil
+}
+
+func (s *CommentService) GetReplies(ctx context.Context, articleId, commentId, userId int, flat bool) (*[]models.Comment, error) {
+	parent, err := s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if parent.ArticleId != articleId {
+		return nil, messages.ErrCommentNotFound
+	}
+	if parent.Status != models.CommentStatusApproved && parent.UserId != userId {
+		settings, err := s.CommentRepository.GetSettings(ctx, articleId)
+		if err != nil {
+			return nil, err
+		}
+		manager, err := s.canManage(ctx, settings, userId)
+		if err != nil {
+			return nil, err
+		}
+		if !manager {
+			return nil, messages.ErrCommentNotFound
+		}
+	}
+
+	comments, err := s.loadReplies(ctx, articleId, []models.Comment{*parent}, parent.Depth, s.cfg.Comments.MaxReplies, userId, flat)
+	if err != nil {
+		return nil, err
+	}
+
+	if flat {
+		replies := (*comments)[1:]
+		return &replies, nil
+	}
+	return &(*comments)[0].Replies, nil
+}
+
+// loadReplies attaches at most maxReplies descendants to roots in path order,
+// so a truncated thread keeps its upper levels and clients can fetch the rest
+// through GetReplies using replies_count as a hint.
+func (s *CommentService) loadReplies(ctx context.Context, articleId int, roots []models.Comment, depth, maxReplies, viewerId int, flat bool) (*[]models.Comment, error) {
+	paths := make([]string, len(roots))
+	for i, root := range roots {
+		paths[i] = root.Path
+	}
+
+	descendants, err := s.CommentRepository.GetDescendants(ctx, articleId, paths, depth+s.cfg.Comments.LoadDepth, maxReplies)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingComments, err)
+	}
+
+	comments := append(roots, descendants...)
+	err = s.setVotes(ctx, comments, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.setMentions(ctx, comments)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := buildCommentTree(comments)
+	if flat {
+		comments := flattenCommentTree(tree)
+		return &comments, nil
+	}
+	return &tree, nil
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, req *models.CommentRequest, articleId, commentId, userId int) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	comment, err := s.getEditableComment(ctx, articleId, commentId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Content = req.Content
+	err = s.CommentRepository.UpdateComment(ctx, comment, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var mentions []models.Mention
+	if comment.Status == models.CommentStatusApproved {
+		mentions, err = s.MentionService.SyncMentions(ctx, models.MentionSourceComment, commentId, comment.UserId, comment.Content)
+		if err != nil {
+			log.Printf("Syncing mentions for comment %d failed: %v", commentId, err)
+		}
+	}
+
+	comment, err = s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Mentions = mentions
+	return comment, nil
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, articleId, commentId, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	comment, err := s.getEditableComment(ctx, articleId, commentId, userId)
+	if err != nil {
+		return err
+	}
+
+	err = s.CommentRepository.DeleteComment(ctx, comment, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.MentionService.SyncMentions(ctx, models.MentionSourceComment, commentId, userId, "")
+	if err != nil {
+		log.Printf("Clearing mentions for comment %d failed: %v", commentId, err)
+	}
+
+	return nil
+}
+
+func (s *CommentService) GetCommentHistory(ctx context.Context, articleId, commentId, userId int) (*[]models.CommentEdit, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	moderator, err := s.isModerator(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !moderator {
+		return nil, messages.ErrForbidden
+	}
+
+	comment, err := s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ArticleId != articleId {
+		return nil, messages.ErrCommentNotFound
+	}
+
+	edits, err := s.CommentRepository.GetCommentHistory(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	return &edits, nil
+}
+
+func (s *CommentService) Vote(ctx context.Context, articleId, commentId, userId, value int) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	comment, err := s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ArticleId != articleId || comment.DeletedAt != nil || comment.Status != models.CommentStatusApproved {
+		return nil, messages.ErrCommentNotFound
+	}
+
+	err = s.CommentRepository.SetVote(ctx, commentId, userId, value)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err = s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.MyVote = value
+	return comment, nil
+}
+
+func (s *CommentService) GetSettings(ctx context.Context, articleId int) (*models.CommentSettings, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.CommentRepository.GetSettings(ctx, articleId)
+}
+
+func (s *CommentService) UpdateSettings(ctx context.Context, req *models.CommentSettingsRequest, articleId, userId int) (*models.CommentSettings, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings, err := s.CommentRepository.GetSettings(ctx, articleId)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := s.canManage(ctx, settings, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !manager {
+		return nil, messages.ErrForbidden
+	}
+
+	settings.Locked = req.Locked
+	settings.Audience = req.Audience
+	settings.RequireApproval = req.RequireApproval
+
+	err = s.CommentRepository.SaveSettings(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *CommentService) GetPendingComments(ctx context.Context, articleId, userId int) (*[]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings, err := s.CommentRepository.GetSettings(ctx, articleId)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := s.canManage(ctx, settings, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !manager {
+		return nil, messages.ErrForbidden
+	}
+
+	comments, err := s.CommentRepository.GetPendingComments(ctx, articleId)
+	if err != nil {
+		return nil, err
+	}
 	return &comments, nil
 }
+
+func (s *CommentService) ModerateComment(ctx context.Context, articleId, commentId, userId int, approve bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	settings, err := s.CommentRepository.GetSettings(ctx, articleId)
+	if err != nil {
+		return err
+	}
+
+	manager, err := s.canManage(ctx, settings, userId)
+	if err != nil {
+		return err
+	}
+	if !manager {
+		return messages.ErrForbidden
+	}
+
+	comment, err := s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return err
+	}
+	if comment.ArticleId != articleId {
+		return messages.ErrCommentNotFound
+	}
+
+	status := models.CommentStatusRejected
+	if approve {
+		status = models.CommentStatusApproved
+	}
+
+	err = s.CommentRepository.SetStatus(ctx, comment, status)
+	if err != nil {
+		return err
+	}
+
+	if approve {
+		_, err = s.MentionService.SyncMentions(ctx, models.MentionSourceComment, comment.Id, comment.UserId, comment.Content)
+		if err != nil {
+			log.Printf("Syncing mentions for comment %d failed: %v", comment.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *CommentService) canManage(ctx context.Context, settings *models.CommentSettings, userId int) (bool, error) {
+	if settings.AuthorId == userId {
+		return true, nil
+	}
+	return s.isModerator(ctx, userId)
+}
+
+func (s *CommentService) checkAudience(ctx context.Context, settings *models.CommentSettings, userId int) error {
+	if settings.Locked {
+		return messages.ErrCommentsLocked
+	}
+
+	switch settings.Audience {
+	case models.CommentAudienceFollowers:
+		following, err := s.FollowRepository.IsFollowing(ctx, userId, settings.AuthorId)
+		if err != nil {
+			return err
+		}
+		if !following {
+			return messages.ErrFollowersOnly
+		}
+	case models.CommentAudienceVerified:
+		user, err := s.UserRepository.GetUserById(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if user.EmailVerifiedAt == nil {
+			return messages.ErrVerifiedOnly
+		}
+	}
+	return nil
+}
+
+func (s *CommentService) setVotes(ctx context.Context, comments []models.Comment, viewerId int) error {
+	if viewerId == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.Id
+	}
+
+	votes, err := s.CommentRepository.GetUserVotes(ctx, viewerId, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].MyVote = votes[comments[i].Id]
+	}
+	return nil
+}
+
+func (s *CommentService) setMentions(ctx context.Context, comments []models.Comment) error {
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.Id
+	}
+
+	mentions, err := s.MentionService.GetMentions(ctx, models.MentionSourceComment, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].Mentions = mentions[comments[i].Id]
+	}
+	return nil
+}
+
+func (s *CommentService) getEditableComment(ctx context.Context, articleId, commentId, userId int) (*models.Comment, error) {
+	comment, err := s.CommentRepository.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ArticleId != articleId || comment.DeletedAt != nil {
+		return nil, messages.ErrCommentNotFound
+	}
+	if comment.UserId == userId {
+		return comment, nil
+	}
+
+	moderator, err := s.isModerator(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !moderator {
+		return nil, messages.ErrForbidden
+	}
+	return comment, nil
+}
+
+func (s *CommentService) isModerator(ctx context.Context, userId int) (bool, error) {
+	user, err := s.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return models.HasPermission(user.Role, models.PermCommentModerate) && user.MfaEnabledAt != nil, nil
+}
+
+func buildCommentTree(comments []models.Comment) []models.Comment {
+	ids := make(map[int]bool, len(comments))
+	for _, comment := range comments {
+		ids[comment.Id] = true
+	}
+
+	var roots []models.Comment
+	children := make(map[int][]models.Comment)
+	for _, comment := range comments {
+		if comment.ParentId == nil || !ids[*comment.ParentId] {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentId] = append(children[*comment.ParentId], comment)
+	}
+
+	var attach func(comment *models.Comment)
+	attach = func(comment *models.Comment) {
+		comment.Replies = children[comment.Id]
+		for i := range comment.Replies {
+			attach(&comment.Replies[i])
+		}
+	}
+
+	for i := range roots {
+		attach(&roots[i])
+	}
+	return roots
+}
+
+func flattenCommentTree(tree []models.Comment) []models.Comment {
+	var comments []models.Comment
+	for _, comment := range tree {
+		replies := comment.Replies
+		comment.Replies = nil
+		comments = append(comments, comment)
+		comments = append(comments, flattenCommentTree(replies)...)
+	}
+	return comments
+}
+
+func encodeCommentCursor(sort string, comment models.Comment) string {
+	cursor := models.CommentCursor{Id: comment.Id}
+	if sort == models.CommentSortTop {
+		cursor.Score = comment.Score
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCommentCursor(value string) (*models.CommentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, messages.ErrInvalidCursor
+	}
+
+	var cursor models.CommentCursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil {
+		return nil, messages.ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"restapp/config"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"time"
+)
+
+type FeedProvider interface {
+	GetFeed(ctx context.Context, userId int, after *models.FeedCursor, limit int) ([]models.Article, error)
+}
+
+type FanOutOnReadFeed struct {
+	r repositories.ArticleRepositoryInterface
+}
+
+func NewFanOutOnReadFeed(r repositories.ArticleRepositoryInterface) *FanOutOnReadFeed {
+	return &FanOutOnReadFeed{r: r}
+}
+
+func (f *FanOutOnReadFeed) GetFeed(ctx context.Context, userId int, after *models.FeedCursor, limit int) ([]models.Article, error) {
+	afterId := 0
+	if after != nil {
+		afterId = after.Id
+	}
+	return f.r.GetFollowedArticles(ctx, userId, afterId, limit)
+}
+
+type FeedServiceInterface interface {
+	GetFeed(ctx context.Context, userId int, cursor string, limit int) (*models.Feed, error)
+}
+
+type FeedService struct {
+	p   FeedProvider
+	m   MentionServiceInterface
+	cfg *config.Config
+}
+
+func NewFeedService(p FeedProvider, m MentionServiceInterface, cfg *config.Config) *FeedService {
+	return &FeedService{p: p, m: m, cfg: cfg}
+}
+
+func (s *FeedService) GetFeed(ctx context.Context, userId int, cursor string, limit int) (*models.Feed, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = s.cfg.Feed.PageSize
+	}
+	if limit > s.cfg.Feed.MaxPageSize {
+		limit = s.cfg.Feed.MaxPageSize
+	}
+
+	var after *models.FeedCursor
+	if cursor != "" {
+		after = &models.FeedCursor{}
+		err := decodeCursor(cursor, after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	articles, err := s.p.GetFeed(ctx, userId, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingFeed, err)
+	}
+
+	feed := models.Feed{}
+	if len(articles) > limit {
+		articles = articles[:limit]
+		feed.NextCursor = encodeCursor(models.FeedCursor{Id: articles[len(articles)-1].Id})
+	}
+
+	ids := make([]int, len(articles))
+	for i, article := range articles {
+		ids[i] = article.Id
+	}
+
+	mentions, err := s.m.GetMentions(ctx, models.MentionSourceArticle, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingFeed, err)
+	}
+	for i := range articles {
+		articles[i].Mentions = mentions[articles[i].Id]
+	}
+
+	feed.Articles = articles
+	return &feed, nil
+}
+
+func encodeCursor(cursor interface{}) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, cursor interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return messages.ErrInvalidCursor
+	}
+
+	err = json.Unmarshal(data, cursor)
+	if err != nil {
+		return messages.ErrInvalidCursor
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"restapp/config"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"time"
+)
+
+type FollowServiceInterface interface {
+	Follow(ctx context.Context, followerId, followeeId int) error
+	Unfollow(ctx context.Context, followerId, followeeId int) error
+	GetFollowers(ctx context.Context, userId int, cursor string, limit int) (*models.FollowList, error)
+	GetFollowing(ctx context.Context, userId int, cursor string, limit int) (*models.FollowList, error)
+}
+
+type FollowService struct {
+	r   repositories.FollowRepositoryInterface
+	u   repositories.UserRepositoryInterface
+	cfg *config.Config
+}
+
+func NewFollowService(r repositories.FollowRepositoryInterface, u repositories.UserRepositoryInterface, cfg *config.Config) *FollowService {
+	return &FollowService{r: r, u: u, cfg: cfg}
+}
+
+func (s *FollowService) Follow(ctx context.Context, followerId, followeeId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if followerId == followeeId {
+		return messages.ErrCannotFollowSelf
+	}
+
+	_, err := s.u.GetProfile(ctx, followeeId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.r.Follow(ctx, followerId, followeeId)
+	return err
+}
+
+func (s *FollowService) Unfollow(ctx context.Context, followerId, followeeId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.r.Unfollow(ctx, followerId, followeeId)
+	return err
+}
+
+func (s *FollowService) GetFollowers(ctx context.Context, userId int, cursor string, limit int) (*models.FollowList, error) {
+	return s.list(ctx, userId, cursor, limit, s.r.GetFollowers)
+}
+
+func (s *FollowService) GetFollowing(ctx context.Context, userId int, cursor string, limit int) (*models.FollowList, error) {
+	return s.list(ctx, userId, cursor, limit, s.r.GetFollowing)
+}
+
+func (s *FollowService) list(ctx context.Context, userId int, cursor string, limit int, fetch func(context.Context, int, *models.FollowCursor, int) ([]models.FollowUser, error)) (*models.FollowList, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = s.cfg.Feed.PageSize
+	}
+	if limit > s.cfg.Feed.MaxPageSize {
+		limit = s.cfg.Feed.MaxPageSize
+	}
+
+	var after *models.FollowCursor
+	if cursor != "" {
+		after = &models.FollowCursor{}
+		err := decodeCursor(cursor, after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := s.u.GetProfile(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := fetch(ctx, userId, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	list := models.FollowList{}
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+		list.NextCursor = encodeCursor(models.FollowCursor{FollowedAt: last.FollowedAt, Id: last.Id})
+	}
+
+	list.Users = users
+	return &list, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var mentionPattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_])@([\p{L}\p{N}_]{3,50})`)
+
+type MentionServiceInterface interface {
+	SyncMentions(ctx context.Context, sourceType string, sourceId, actorId int, content string) ([]models.Mention, error)
+	GetMentions(ctx context.Context, sourceType string, sourceIds []int) (map[int][]models.Mention, error)
+}
+
+type MentionService struct {
+	r repositories.MentionRepositoryInterface
+}
+
+func NewMentionService(r repositories.MentionRepositoryInterface) *MentionService {
+	return &MentionService{r: r}
+}
+
+func (s *MentionService) SyncMentions(ctx context.Context, sourceType string, sourceId, actorId int, content string) ([]models.Mention, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	parsed := parseMentions(content)
+
+	usernames := make([]string, 0, len(parsed))
+	for _, mention := range parsed {
+		usernames = append(usernames, mention.Username)
+	}
+
+	users, err := s.r.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	byUsername := make(map[string]models.User, len(users))
+	for _, user := range users {
+		byUsername[strings.ToLower(user.Username)] = user
+	}
+
+	mentions := make([]models.Mention, 0, len(parsed))
+	for _, mention := range parsed {
+		user, ok := byUsername[strings.ToLower(mention.Username)]
+		if !ok {
+			continue
+		}
+
+		mention.UserId = user.Id
+		mention.Username = user.Username
+		mentions = append(mentions, mention)
+	}
+
+	err = s.r.ReplaceMentions(ctx, sourceType, sourceId, actorId, mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	return mentions, nil
+}
+
+func (s *MentionService) GetMentions(ctx context.Context, sourceType string, sourceIds []int) (map[int][]models.Mention, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	mentions, err := s.r.GetMentions(ctx, sourceType, sourceIds)
+	if err != nil {
+		return nil, err
+	}
+
+	bySource := make(map[int][]models.Mention)
+	for _, mention := range mentions {
+		bySource[mention.SourceId] = append(bySource[mention.SourceId], mention)
+	}
+	return bySource, nil
+}
+
+func parseMentions(content string) []models.Mention {
+	var mentions []models.Mention
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		start := match[4] - 1
+		mentions = append(mentions, models.Mention{
+			Username: content[match[4]:match[5]],
+			Offset:   utf8.RuneCountInString(content[:start]),
+			Length:   utf8.RuneCountInString(content[start:match[5]]),
+		})
+	}
+	return mentions
+}
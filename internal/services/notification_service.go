@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"time"
+)
+
+type NotificationServiceInterface interface {
+	GetNotifications(ctx context.Context, userId int, unreadOnly bool) (*[]models.Notification, error)
+	MarkRead(ctx context.Context, id, userId int) error
+}
+
+type NotificationService struct {
+	r repositories.NotificationRepositoryInterface
+}
+
+func NewNotificationService(r repositories.NotificationRepositoryInterface) *NotificationService {
+	return &NotificationService{r: r}
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, userId int, unreadOnly bool) (*[]models.Notification, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	notifications, err := s.r.GetNotifications(ctx, userId, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGettingNotifications, err)
+	}
+
+	return &notifications, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, id, userId int) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.r.MarkRead(ctx, id, userId)
+}
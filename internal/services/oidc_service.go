@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"restapp/config"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/oidc"
+	"restapp/internal/repositories"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxUsernameSlug keeps a slug plus a "_" and six hex digits within the
+// 50 characters usernames allow.
+const maxUsernameSlug = 43
+
+var usernameUnsafe = regexp.MustCompile(`[^\p{L}\p{N}_]+`)
+
+type OIDCServiceInterface interface {
+	AuthURL(ctx context.Context, provider string) (string, error)
+	Callback(ctx context.Context, provider, state, code string, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error)
+}
+
+type OIDCService struct {
+	providers map[string]*oidc.Provider
+	r         repositories.OIDCRepositoryInterface
+	authRepo  repositories.AuthRepositoryInterface
+	userRepo  repositories.UserRepositoryInterface
+	auth      AuthServiceInterface
+	cfg       *config.Config
+}
+
+func NewOIDCService(providers map[string]*oidc.Provider, r repositories.OIDCRepositoryInterface, authRepo repositories.AuthRepositoryInterface, userRepo repositories.UserRepositoryInterface, auth AuthServiceInterface, cfg *config.Config) *OIDCService {
+	return &OIDCService{providers: providers, r: r, authRepo: authRepo, userRepo: userRepo, auth: auth, cfg: cfg}
+}
+
+func (s *OIDCService) AuthURL(ctx context.Context, providerName string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", messages.ErrUnknownProvider
+	}
+
+	state := models.OIDCState{Provider: providerName}
+	for _, value := range []*string{&state.State, &state.CodeVerifier, &state.Nonce} {
+		random, err := oidc.RandomString(32)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+		}
+		*value = random
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", messages.ErrOIDCExchange, err)
+	}
+
+	err = s.r.CreateState(ctx, &state, s.cfg.OIDC.StateExpiration)
+	if err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+func (s *OIDCService) Callback(ctx context.Context, providerName, state, code string, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error) {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, messages.ErrUnknownProvider
+	}
+
+	stored, err := s.r.ConsumeState(ctx, state)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stored.Provider != providerName {
+		return nil, nil, messages.ErrInvalidOIDCState
+	}
+
+	token, err := provider.Exchange(ctx, code, stored.CodeVerifier)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", messages.ErrOIDCExchange, err)
+	}
+
+	identity, err := provider.Identity(ctx, token, stored.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", messages.ErrOIDCExchange, err)
+	}
+
+	userId, err := s.resolveUser(ctx, providerName, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.auth.LoginUser(ctx, userId, client)
+}
+
+func (s *OIDCService) resolveUser(ctx context.Context, providerName string, identity *oidc.Identity) (int, error) {
+	userId, err := s.r.GetIdentityUser(ctx, providerName, identity.Subject)
+	if err == nil {
+		return userId, nil
+	}
+	if !errors.Is(err, messages.ErrUserNotFound) {
+		return 0, err
+	}
+
+	if identity.Email == "" {
+		return 0, messages.ErrOIDCEmailMissing
+	}
+	if !identity.EmailVerified {
+		return 0, messages.ErrOIDCEmailUnverified
+	}
+
+	user, err := s.authRepo.GetUserByEmail(ctx, identity.Email)
+	if err != nil {
+		if !errors.Is(err, messages.ErrUserNotFound) {
+			return 0, err
+		}
+
+		user, err = s.createUser(ctx, identity)
+		if err != nil {
+			return 0, err
+		}
+	} else if user.EmailVerifiedAt == nil {
+		// Anyone can register an unverified address, so linking here would hand
+		// the provider account to whoever claimed the email first.
+		return 0, messages.ErrOIDCAccountUnverified
+	}
+
+	err = s.r.LinkIdentity(ctx, user.Id, providerName, identity.Subject, identity.Email)
+	if err != nil {
+		return 0, err
+	}
+
+	return user.Id, nil
+}
+
+func (s *OIDCService) createUser(ctx context.Context, identity *oidc.Identity) (*models.User, error) {
+	password, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", messages.ErrHashingPassword, err)
+	}
+
+	username := "user"
+	for _, name := range []string{identity.Name, strings.SplitN(identity.Email, "@", 2)[0]} {
+		slug := usernameSlug(name)
+		if models.ValidateUsername(slug) == nil {
+			username = slug
+			break
+		}
+	}
+
+	var user *models.User
+	for attempt := 0; ; attempt++ {
+		candidate := username
+		if attempt > 0 {
+			suffix, err := randomHex(3)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", messages.ErrGeneratingToken, err)
+			}
+			candidate = username + "_" + suffix
+		}
+
+		err = models.ValidateUsername(candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err = s.authRepo.Register(ctx, &models.User{
+			Username:  candidate,
+			Password:  string(hashedPassword),
+			Email:     identity.Email,
+			Role:      models.DefaultRole,
+			CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+			UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
+		})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, messages.ErrUsernameAlreadyExists) || attempt == 4 {
+			return nil, err
+		}
+	}
+
+	err = s.userRepo.MarkEmailVerified(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// usernameSlug turns a provider display name into a username: runs of
+// characters mentions cannot match become "_", and the result leaves room for
+// the suffix createUser adds on a collision.
+func usernameSlug(name string) string {
+	slug := strings.Trim(usernameUnsafe.ReplaceAllString(name, "_"), "_")
+	if runes := []rune(slug); len(runes) > maxUsernameSlug {
+		slug = strings.TrimRight(string(runes[:maxUsernameSlug]), "_")
+	}
+	return slug
+}
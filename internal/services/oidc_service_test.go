@@ -0,0 +1,575 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"restapp/config"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/oidc"
+	"restapp/internal/repositories"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	fakeClientID     = "article-hub"
+	fakeClientSecret = "client-secret"
+	fakeRedirectURL  = "http://localhost/auth/oidc/fake/callback"
+)
+
+type fakeGrant struct {
+	challenge string
+	nonce     string
+	userinfo  map[string]interface{}
+}
+
+// fakeOIDCProvider is an in-process OpenID Connect provider serving
+// discovery, token and userinfo endpoints. Codes are single use and the token
+// endpoint enforces PKCE with S256.
+type fakeOIDCProvider struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	issuer   string
+	audience string
+	nonce    string
+	grants   map[string]fakeGrant
+	tokens   map[string]map[string]interface{}
+	nextCode int
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+
+	p := &fakeOIDCProvider{
+		audience: fakeClientID,
+		grants:   make(map[string]fakeGrant),
+		tokens:   make(map[string]map[string]interface{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/userinfo", p.userinfo)
+
+	p.server = httptest.NewServer(mux)
+	p.issuer = p.server.URL
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *fakeOIDCProvider) config() config.OIDCProvider {
+	return config.OIDCProvider{
+		Issuer:       p.server.URL,
+		ClientID:     fakeClientID,
+		ClientSecret: fakeClientSecret,
+		RedirectURL:  fakeRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+func (p *fakeOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.issuer,
+		"authorization_endpoint": p.server.URL + "/authorize",
+		"token_endpoint":         p.server.URL + "/token",
+		"userinfo_endpoint":      p.server.URL + "/userinfo",
+	})
+}
+
+// authorize plays the user approving the login: it checks the authorization
+// URL the app built and returns the code the provider would redirect with.
+func (p *fakeOIDCProvider) authorize(t *testing.T, authURL string, userinfo map[string]interface{}) (state string, code string) {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, p.server.URL+"/authorize?") {
+		t.Fatalf("authorization URL %q does not use the discovered endpoint", authURL)
+	}
+
+	query := parsed.Query()
+	for key, want := range map[string]string{
+		"response_type":         "code",
+		"client_id":             fakeClientID,
+		"redirect_uri":          fakeRedirectURL,
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(key); got != want {
+			t.Fatalf("authorization URL %s = %q, want %q", key, got, want)
+		}
+	}
+	if query.Get("state") == "" || query.Get("nonce") == "" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL %q lacks state, nonce or code_challenge", authURL)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextCode++
+	code = "code-" + strconv.Itoa(p.nextCode)
+	p.grants[code] = fakeGrant{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		userinfo:  userinfo,
+	}
+
+	return query.Get("state"), code
+}
+
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != fakeClientID ||
+		r.PostForm.Get("client_secret") != fakeClientSecret ||
+		r.PostForm.Get("redirect_uri") != fakeRedirectURL {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	grant, ok := p.grants[code]
+	delete(p.grants, code)
+	if !ok || oidc.Challenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	nonce := grant.nonce
+	if p.nonce != "" {
+		nonce = p.nonce
+	}
+
+	idToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   p.issuer,
+		"aud":   p.audience,
+		"sub":   grant.userinfo["sub"],
+		"nonce": nonce,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("fake-provider-key"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	accessToken := "access-" + code
+	p.tokens[accessToken] = grant.userinfo
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func (p *fakeOIDCProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, ok := p.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type fakeOIDCRepository struct {
+	mu         sync.Mutex
+	states     map[string]models.OIDCState
+	identities map[string]int
+}
+
+func (r *fakeOIDCRepository) CreateState(ctx context.Context, state *models.OIDCState, ttl int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[state.State] = *state
+	return nil
+}
+
+func (r *fakeOIDCRepository) ConsumeState(ctx context.Context, state string) (*models.OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.states[state]
+	if !ok {
+		return nil, messages.ErrInvalidOIDCState
+	}
+	delete(r.states, state)
+	return &stored, nil
+}
+
+func (r *fakeOIDCRepository) GetIdentityUser(ctx context.Context, provider, subject string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userId, ok := r.identities[provider+"/"+subject]
+	if !ok {
+		return 0, messages.ErrUserNotFound
+	}
+	return userId, nil
+}
+
+func (r *fakeOIDCRepository) LinkIdentity(ctx context.Context, userId int, provider, subject, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.identities[provider+"/"+subject] = userId
+	return nil
+}
+
+// fakeUsers backs both AuthRepositoryInterface and the parts of
+// UserRepositoryInterface the OIDC flow uses.
+type fakeUsers struct {
+	repositories.UserRepositoryInterface
+
+	mu    sync.Mutex
+	users []*models.User
+}
+
+func (r *fakeUsers) Register(ctx context.Context, user *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return nil, messages.ErrEmailAlreadyExists
+		}
+		if strings.EqualFold(existing.Username, user.Username) {
+			return nil, messages.ErrUsernameAlreadyExists
+		}
+	}
+
+	created := *user
+	created.Id = len(r.users) + 1
+	r.users = append(r.users, &created)
+	return &created, nil
+}
+
+func (r *fakeUsers) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, messages.ErrUserNotFound
+}
+
+func (r *fakeUsers) MarkEmailVerified(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	verifiedAt := time.Now().Format("2006-01-02 15:04:05")
+	r.users[id-1].EmailVerifiedAt = &verifiedAt
+	return nil
+}
+
+// fakeLogin records which user the OIDC flow signed in.
+type fakeLogin struct {
+	AuthServiceInterface
+	userId int
+}
+
+func (a *fakeLogin) LoginUser(ctx context.Context, userId int, client models.ClientInfo) (*models.TokenPair, *models.MfaChallenge, error) {
+	a.userId = userId
+	return &models.TokenPair{Token: "app-token"}, nil, nil
+}
+
+type oidcFixture struct {
+	provider *fakeOIDCProvider
+	repo     *fakeOIDCRepository
+	users    *fakeUsers
+	login    *fakeLogin
+	service  *OIDCService
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+
+	f := &oidcFixture{
+		provider: newFakeOIDCProvider(t),
+		repo:     &fakeOIDCRepository{states: make(map[string]models.OIDCState), identities: make(map[string]int)},
+		users:    &fakeUsers{},
+		login:    &fakeLogin{},
+	}
+
+	cfg := &config.Config{}
+	cfg.OIDC.StateExpiration = 600
+	providers := map[string]*oidc.Provider{
+		"fake": oidc.NewProvider("fake", f.provider.config(), f.provider.server.Client()),
+	}
+	f.service = NewOIDCService(providers, f.repo, f.users, f.users, f.login, cfg)
+
+	return f
+}
+
+// signIn runs the full authorization code flow for the given userinfo claims.
+func (f *oidcFixture) signIn(t *testing.T, userinfo map[string]interface{}) error {
+	t.Helper()
+
+	authURL, err := f.service.AuthURL(context.Background(), "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, code := f.provider.authorize(t, authURL, userinfo)
+	_, _, err = f.service.Callback(context.Background(), "fake", state, code, models.ClientInfo{})
+	return err
+}
+
+func (f *oidcFixture) addUser(t *testing.T, email string, verified bool) *models.User {
+	t.Helper()
+
+	user, err := f.users.Register(context.Background(), &models.User{Username: strings.Split(email, "@")[0], Email: email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified {
+		err = f.users.MarkEmailVerified(context.Background(), user.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return user
+}
+
+func userinfo(subject, email string, verified bool) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                subject,
+		"email":              email,
+		"email_verified":     verified,
+		"preferred_username": strings.Split(email, "@")[0],
+	}
+}
+
+func TestOIDCCreatesUser(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	err := f.signIn(t, userinfo("subject-1", "new@example.com", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := f.users.GetUserByEmail(context.Background(), "new@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.login.userId != user.Id {
+		t.Errorf("signed in user %d, want new user %d", f.login.userId, user.Id)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("new user's email is not marked verified")
+	}
+	if user.Role != models.DefaultRole {
+		t.Errorf("new user role = %q, want %q", user.Role, models.DefaultRole)
+	}
+
+	err = f.signIn(t, userinfo("subject-1", "new@example.com", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.users.users) != 1 || f.login.userId != user.Id {
+		t.Errorf("second sign-in created a user or signed in %d, want linked user %d", f.login.userId, user.Id)
+	}
+}
+
+func TestOIDCSlugifiesUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		want     string
+	}{
+		{"display name", "John Smith", "john@example.com", "John_Smith"},
+		{"too short", "J", "jsmith@example.com", "jsmith"},
+		{"reserved", "deleted-7", "deleted-7@example.com", "deleted_7"},
+		{"nothing usable", "..", "@@example.com", "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t)
+
+			info := userinfo("subject-1", tt.email, true)
+			info["preferred_username"] = tt.username
+			err := f.signIn(t, info)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := f.users.GetUserByEmail(context.Background(), tt.email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Username != tt.want {
+				t.Errorf("username = %q, want %q", user.Username, tt.want)
+			}
+			if err := models.ValidateUsername(user.Username); err != nil {
+				t.Errorf("username %q fails validation: %v", user.Username, err)
+			}
+		})
+	}
+}
+
+func TestOIDCLinksVerifiedUser(t *testing.T) {
+	f := newOIDCFixture(t)
+	existing := f.addUser(t, "Reader@Example.com", true)
+
+	err := f.signIn(t, userinfo("subject-2", "reader@example.com", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.login.userId != existing.Id {
+		t.Errorf("signed in user %d, want existing user %d", f.login.userId, existing.Id)
+	}
+	if len(f.users.users) != 1 {
+		t.Errorf("%d users exist, want the existing user only", len(f.users.users))
+	}
+	if f.repo.identities["fake/subject-2"] != existing.Id {
+		t.Error("identity was not linked to the existing user")
+	}
+}
+
+func TestOIDCRefusesUnverifiedLocalUser(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.addUser(t, "victim@example.com", false)
+
+	err := f.signIn(t, userinfo("subject-3", "victim@example.com", true))
+	if !errors.Is(err, messages.ErrOIDCAccountUnverified) {
+		t.Fatalf("err = %v, want %v", err, messages.ErrOIDCAccountUnverified)
+	}
+	if len(f.repo.identities) != 0 || f.login.userId != 0 {
+		t.Error("identity was linked to an account with an unverified email")
+	}
+}
+
+func TestOIDCRejectsUnverifiedProviderEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	err := f.signIn(t, userinfo("subject-4", "unverified@example.com", false))
+	if !errors.Is(err, messages.ErrOIDCEmailUnverified) {
+		t.Fatalf("err = %v, want %v", err, messages.ErrOIDCEmailUnverified)
+	}
+	if len(f.users.users) != 0 {
+		t.Error("user was created from an unverified provider email")
+	}
+}
+
+func TestOIDCConsumesState(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	authURL, err := f.service.AuthURL(context.Background(), "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := f.provider.authorize(t, authURL, userinfo("subject-5", "state@example.com", true))
+
+	_, _, err = f.service.Callback(context.Background(), "fake", state, code, models.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = f.service.Callback(context.Background(), "fake", state, code, models.ClientInfo{})
+	if !errors.Is(err, messages.ErrInvalidOIDCState) {
+		t.Fatalf("replayed state: err = %v, want %v", err, messages.ErrInvalidOIDCState)
+	}
+
+	_, _, err = f.service.Callback(context.Background(), "fake", "unknown-state", code, models.ClientInfo{})
+	if !errors.Is(err, messages.ErrInvalidOIDCState) {
+		t.Fatalf("unknown state: err = %v, want %v", err, messages.ErrInvalidOIDCState)
+	}
+}
+
+func TestOIDCEnforcesPKCE(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	authURL, err := f.service.AuthURL(context.Background(), "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := f.provider.authorize(t, authURL, userinfo("subject-6", "pkce@example.com", true))
+
+	stored := f.repo.states[state]
+	stored.CodeVerifier = "not-the-verifier"
+	f.repo.states[state] = stored
+
+	_, _, err = f.service.Callback(context.Background(), "fake", state, code, models.ClientInfo{})
+	if !errors.Is(err, messages.ErrOIDCExchange) {
+		t.Fatalf("err = %v, want %v", err, messages.ErrOIDCExchange)
+	}
+	if f.login.userId != 0 {
+		t.Error("signed in with a wrong code verifier")
+	}
+}
+
+func TestOIDCChecksIdToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		audience string
+		nonce    string
+	}{
+		{name: "nonce mismatch", audience: fakeClientID, nonce: "other-nonce"},
+		{name: "audience mismatch", audience: "other-client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t)
+			f.provider.audience = tt.audience
+			f.provider.nonce = tt.nonce
+
+			err := f.signIn(t, userinfo("subject-7", "token@example.com", true))
+			if !errors.Is(err, messages.ErrOIDCExchange) {
+				t.Fatalf("err = %v, want %v", err, messages.ErrOIDCExchange)
+			}
+			if f.login.userId != 0 {
+				t.Error("signed in with an invalid id_token")
+			}
+		})
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.provider.issuer = "https://impostor.example.com"
+
+	_, err := f.service.AuthURL(context.Background(), "fake")
+	if !errors.Is(err, messages.ErrOIDCExchange) {
+		t.Fatalf("err = %v, want %v", err, messages.ErrOIDCExchange)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"restapp/internal/models"
+	"sync"
+	"time"
+)
+
+type revocationCache struct {
+	mu       sync.RWMutex
+	revoked  map[string]int64
+	versions map[int]int
+	sessions map[string]int64
+	lastSeen map[string]time.Time
+}
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{
+		revoked:  make(map[string]int64),
+		versions: make(map[int]int),
+		sessions: make(map[string]int64),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+func (c *revocationCache) isRevoked(jti string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.revoked[jti]
+	return ok
+}
+
+func (c *revocationCache) revoke(jti string, expiresAt int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revoked[jti] = expiresAt
+}
+
+func (c *revocationCache) version(userId int) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	version, ok := c.versions[userId]
+	return version, ok
+}
+
+func (c *revocationCache) setVersion(userId int, version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions[userId] = version
+}
+
+func (c *revocationCache) isSessionRevoked(sessionId string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.sessions[sessionId]
+	return ok
+}
+
+func (c *revocationCache) revokeSession(sessionId string, expiresAt int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sessions[sessionId] = expiresAt
+	delete(c.lastSeen, sessionId)
+}
+
+// touch reports whether the session's last-seen time is older than interval
+// and, if so, records now as the new last-seen time.
+func (c *revocationCache) touch(sessionId string, now time.Time, interval time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if seen, ok := c.lastSeen[sessionId]; ok && now.Sub(seen) < interval {
+		return false
+	}
+	c.lastSeen[sessionId] = now
+	return true
+}
+
+func (c *revocationCache) reset(tokens []models.RevokedToken, sessions []models.RevokedToken) {
+	now := time.Now().Unix()
+	revoked := make(map[string]int64, len(tokens))
+	for _, token := range tokens {
+		if token.ExpiresAt >= now {
+			revoked[token.Jti] = token.ExpiresAt
+		}
+	}
+
+	revokedSessions := make(map[string]int64, len(sessions))
+	for _, session := range sessions {
+		if session.ExpiresAt >= now {
+			revokedSessions[session.Jti] = session.ExpiresAt
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revoked = revoked
+	c.versions = make(map[int]int)
+	c.sessions = revokedSessions
+	c.lastSeen = make(map[string]time.Time)
+}
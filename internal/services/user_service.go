@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"restapp/internal/messages"
+	"restapp/internal/models"
+	"restapp/internal/repositories"
+	"time"
+)
+
+type UserServiceInterface interface {
+	GetMe(ctx context.Context, userId int) (*models.CurrentUser, error)
+	GetUser(ctx context.Context, userId int) (*models.PublicUser, error)
+	UpdateMe(ctx context.Context, userId int, req *models.UserUpdateRequest) (*models.CurrentUser, error)
+}
+
+type UserService struct {
+	r repositories.UserRepositoryInterface
+}
+
+func NewUserService(r repositories.UserRepositoryInterface) *UserService {
+	return &UserService{r: r}
+}
+
+func (s *UserService) GetMe(ctx context.Context, userId int) (*models.CurrentUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := s.r.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.r.GetProfile(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewCurrentUser(user, profile.ArticlesCount), nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, userId int) (*models.PublicUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.r.GetProfile(ctx, userId)
+}
+
+func (s *UserService) UpdateMe(ctx context.Context, userId int, req *models.UserUpdateRequest) (*models.CurrentUser, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.r.UpdateProfile(ctx, userId, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetMe(ctx, userId)
+}
+
+func checkVerified(ctx context.Context, r repositories.UserRepositoryInterface, userId int) error {
+	user, err := r.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return messages.ErrEmailNotVerified
+	}
+	return nil
+}